@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"golang.org/x/tools/go/analysis"
+)
+
+const directivePrefix = "//gopsqlfmt:"
+
+// directive is the settings written in the magic comments, ex) //gopsqlfmt:ignore, //gopsqlfmt:indent=tab
+type directive struct {
+	// ignore skips formatting
+	ignore bool
+	// format formats the string even if it is not detected as sql
+	format bool
+	// overrides change the config for the string
+	overrides []func(*fmtconf.Config)
+}
+
+var directiveOverrides = map[string]map[string]func(*fmtconf.Config){
+	"indent": {
+		"tab":        func(c *fmtconf.Config) { c.IndentType = fmtconf.INDENT_TYPE_TAB },
+		"two-spaces": func(c *fmtconf.Config) { c.IndentType = fmtconf.INDENT_TYPE_TWO_SPACES },
+	},
+	"keyword-case": {
+		"upper":    func(c *fmtconf.Config) { c.KeywordCase = fmtconf.KEYWORD_CASE_UPPER },
+		"lower":    func(c *fmtconf.Config) { c.KeywordCase = fmtconf.KEYWORD_CASE_LOWER },
+		"preserve": func(c *fmtconf.Config) { c.KeywordCase = fmtconf.KEYWORD_CASE_PRESERVE },
+	},
+	"identifier-case": {
+		"lower":    func(c *fmtconf.Config) { c.Identifier.Case = fmtconf.IDENTIFIER_CASE_LOWER },
+		"preserve": func(c *fmtconf.Config) { c.Identifier.Case = fmtconf.IDENTIFIER_CASE_PRESERVE },
+	},
+	"quote-identifiers": {
+		"as-needed": func(c *fmtconf.Config) { c.Identifier.Quote = fmtconf.QUOTE_IDENTIFIERS_AS_NEEDED },
+		"always":    func(c *fmtconf.Config) { c.Identifier.Quote = fmtconf.QUOTE_IDENTIFIERS_ALWAYS },
+	},
+	"type-cast-style": {
+		"double-colon": func(c *fmtconf.Config) { c.TypeCastStyle = fmtconf.TYPE_CAST_STYLE_DOUBLE_COLON },
+		"cast":         func(c *fmtconf.Config) { c.TypeCastStyle = fmtconf.TYPE_CAST_STYLE_CAST },
+	},
+	"func-name-type-case": {
+		"upper": func(c *fmtconf.Config) { c.FuncCallConfig.FuncNameTypeCase = fmtconf.FUNC_NAME_TYPE_CASE_UPPER },
+		"lower": func(c *fmtconf.Config) { c.FuncCallConfig.FuncNameTypeCase = fmtconf.FUNC_NAME_TYPE_CASE_LOWER },
+	},
+}
+
+func (d directive) merge(other directive) directive {
+	return directive{
+		ignore:    d.ignore || other.ignore,
+		format:    d.format || other.format,
+		overrides: append(append([]func(*fmtconf.Config){}, d.overrides...), other.overrides...),
+	}
+}
+
+// config returns conf with the overrides applied
+func (d directive) config(conf *fmtconf.Config) *fmtconf.Config {
+	if len(d.overrides) == 0 {
+		return conf
+	}
+	c := *conf
+	for _, override := range d.overrides {
+		override(&c)
+	}
+	return &c
+}
+
+// fileDirectives are the magic comments of a file
+type fileDirectives struct {
+	fset       *token.FileSet
+	ignoreFile bool
+	// above are the directives written on the lines above a node, keyed by the line of the node
+	above map[int]directive
+	// trailing are the directives written at the end of a line, keyed by the line
+	trailing map[int]directive
+}
+
+func parseFileDirectives(pass *analysis.Pass, astFile *ast.File) *fileDirectives {
+	fd := &fileDirectives{
+		fset:     pass.Fset,
+		above:    map[int]directive{},
+		trailing: map[int]directive{},
+	}
+
+	// the first code position of each line, used to tell a trailing comment from a comment on its own line
+	codeStart := map[int]token.Pos{}
+	ast.Inspect(astFile, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		for _, pos := range []token.Pos{n.Pos(), n.End()} {
+			line := fd.line(pos)
+			if start, ok := codeStart[line]; !ok || pos < start {
+				codeStart[line] = pos
+			}
+		}
+		return true
+	})
+
+	for _, group := range astFile.Comments {
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, directivePrefix) {
+				continue
+			}
+			d, ok := parseDirective(strings.TrimSpace(strings.TrimPrefix(c.Text, directivePrefix)))
+			if !ok {
+				pass.Reportf(c.Pos(), "unknown gopsqlfmt directive: %s", c.Text)
+				continue
+			}
+			if d == nil {
+				fd.ignoreFile = true
+				continue
+			}
+
+			line := fd.line(c.Pos())
+			if start, ok := codeStart[line]; ok && start < c.Pos() {
+				fd.trailing[line] = fd.trailing[line].merge(*d)
+			} else {
+				nodeLine := fd.line(group.End()) + 1
+				fd.above[nodeLine] = fd.above[nodeLine].merge(*d)
+			}
+		}
+	}
+	return fd
+}
+
+// parseDirective parses the text after //gopsqlfmt:, the directive is nil for ignore-file
+func parseDirective(text string) (*directive, bool) {
+	switch text {
+	case "ignore":
+		return &directive{ignore: true}, true
+	case "ignore-file":
+		return nil, true
+	case "format":
+		return &directive{format: true}, true
+	}
+
+	key, value, ok := strings.Cut(text, "=")
+	if !ok {
+		return nil, false
+	}
+	override, ok := directiveOverrides[strings.TrimSpace(key)][strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		return nil, false
+	}
+	return &directive{overrides: []func(*fmtconf.Config){override}}, true
+}
+
+// lookup returns the directives written above the nodes or at the end of the lines of the nodes
+func (fd *fileDirectives) lookup(nodes ...ast.Node) directive {
+	var d directive
+	for _, n := range nodes {
+		d = d.merge(fd.above[fd.line(n.Pos())])
+		d = d.merge(fd.trailing[fd.line(n.Pos())])
+		d = d.merge(fd.trailing[fd.line(n.End())])
+	}
+	return d
+}
+
+func (fd *fileDirectives) line(pos token.Pos) int {
+	return fd.fset.Position(pos).Line
+}
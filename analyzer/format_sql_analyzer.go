@@ -1,12 +1,12 @@
 package analyzer
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
-	"go/format"
 	"go/token"
-	"io/ioutil"
+	"go/types"
+	"strconv"
 	"strings"
 
 	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
@@ -16,8 +16,10 @@ import (
 
 var FormatSQLAnalyzer = &analysis.Analyzer{
 	Name: "format_sql",
-	Doc:  "Only target those of sql string",
+	Doc:  "Only target those of sql string, reports unformatted sql and suggests the formatted sql as a fix",
 	Run:  formatSQLRun,
+	// the funcs that pass their parameter to a query func, see queryFuncFact
+	FactTypes: []analysis.Fact{new(queryFuncFact)},
 }
 
 func formatSQLRun(pass *analysis.Pass) (interface{}, error) {
@@ -26,15 +28,19 @@ func formatSQLRun(pass *analysis.Pass) (interface{}, error) {
 		return nil, err
 	}
 
-	for _, astFile := range pass.Files {
-		var formatErr error
-		isFormatted := false
+	detector := newSQLDetector(pass, conf)
 
+	for _, astFile := range pass.Files {
 		fname := pass.Fset.Position(astFile.Package).Filename
 
-		if strings.HasSuffix(fname, "_gen.go") {
-			// Skip generated files
-			return nil, nil
+		// Skip generated files, ex) // Code generated by sqlc. DO NOT EDIT.
+		if ast.IsGenerated(astFile) || !conf.IsTargetFile(fname) {
+			continue
+		}
+
+		directives := parseFileDirectives(pass, astFile)
+		if directives.ignoreFile {
+			continue
 		}
 
 		ast.Inspect(astFile, func(n ast.Node) bool {
@@ -43,51 +49,192 @@ func formatSQLRun(pass *analysis.Pass) (interface{}, error) {
 			}
 
 			switch x := n.(type) {
+			case *ast.FuncDecl:
+				// ex) //gopsqlfmt:ignore on a func
+				if directives.lookup(x).ignore {
+					return false
+				}
 			case *ast.GenDecl:
-				if x.Tok == token.CONST {
+				declDirective := directives.lookup(x)
+				if declDirective.ignore {
+					return false
+				}
+				if (x.Tok == token.CONST && conf.IsTargetSite(fmtconf.TARGET_SITE_CONST)) ||
+					(x.Tok == token.VAR && conf.IsTargetSite(fmtconf.TARGET_SITE_VAR)) {
 					for _, spec := range x.Specs {
-						vspec := spec.(*ast.ValueSpec)
-						for _, v := range vspec.Values {
-							if basicList, ok := v.(*ast.BasicLit); ok {
-								trimSQL := strings.TrimSpace(strings.NewReplacer([]string{
-									"`", "",
-									`"`, "",
-								}...).Replace(basicList.Value))
-								upperSQL := strings.ToUpper(trimSQL)
-								if strings.HasPrefix(upperSQL, "SELECT") || strings.HasPrefix(upperSQL, "INSERT") || strings.HasPrefix(upperSQL, "UPDATE") || strings.HasPrefix(upperSQL, "DELETE") {
-									result, err := formatter.Format(trimSQL, conf)
-									if err != nil {
-										formatErr = fmt.Errorf("%s: %s\n%s", fname, err.Error(), trimSQL)
-										return false
-									}
-									basicList.Value = fmt.Sprintf("`%s`", result)
-									isFormatted = true
-								}
-							}
+						vspec, ok := spec.(*ast.ValueSpec)
+						if !ok || len(vspec.Names) != len(vspec.Values) {
+							continue
+						}
+						d := declDirective.merge(directives.lookup(vspec))
+						for i, v := range vspec.Values {
+							reportSQLExpr(pass, detector, vspec.Names[i], v, d, conf)
+						}
+					}
+				}
+			case *ast.AssignStmt:
+				// ex) q := "SELECT ...", q = "SELECT ..."
+				if conf.IsTargetSite(fmtconf.TARGET_SITE_ASSIGN) && len(x.Lhs) == len(x.Rhs) {
+					d := directives.lookup(x)
+					for i, v := range x.Rhs {
+						reportSQLExpr(pass, detector, x.Lhs[i], v, d, conf)
+					}
+				}
+			case *ast.KeyValueExpr:
+				// ex) Query{SQL: "SELECT ..."}
+				if conf.IsTargetSite(fmtconf.TARGET_SITE_STRUCT_FIELD) {
+					reportSQLExpr(pass, detector, x.Key, x.Value, directives.lookup(x), conf)
+				}
+			case *ast.CallExpr:
+				// ex) db.QueryContext(ctx, "SELECT ...")
+				if conf.IsTargetSite(fmtconf.TARGET_SITE_FUNC_ARG) {
+					for _, arg := range x.Args {
+						d := directives.lookup(x, arg)
+						if conf.Target.DetectionType == fmtconf.DETECTION_TYPE_PREFIX && !isQueryMethodCall(x, conf) && !d.format {
+							continue
 						}
+						reportSQLExpr(pass, detector, nil, arg, d, conf)
 					}
 				}
 			}
 			return true
 		})
+	}
+	return nil, nil
+}
 
-		if formatErr != nil {
-			return nil, formatErr
+// reportSQLExpr reports value if it is a sql string, dest is the const, variable or field that value is assigned to
+func reportSQLExpr(pass *analysis.Pass, detector *sqlDetector, dest ast.Expr, value ast.Expr, d directive, conf *fmtconf.Config) {
+	if d.ignore {
+		return
+	}
+	basicList, ok := value.(*ast.BasicLit)
+	if !ok || basicList.Kind != token.STRING {
+		return
+	}
+	sql, err := strconv.Unquote(basicList.Value)
+	if err != nil {
+		return
+	}
+	sql = strings.TrimSpace(sql)
+	conf = d.config(conf)
+
+	if d.format {
+		reportSQL(pass, basicList, sql, true, conf)
+		return
+	}
+
+	if conf.Target.DetectionType == fmtconf.DETECTION_TYPE_PREFIX {
+		if !hasSQLPrefix(sql) {
+			return
 		}
+		// only the consts and the arguments of the query methods are known to be sql,
+		// ex) title := "Select a plan to continue" is not reported
+		reportSQL(pass, basicList, sql, dest == nil || isConst(pass, dest), conf)
+		return
+	}
 
-		if isFormatted {
-			var buf bytes.Buffer
-			if err := format.Node(&buf, pass.Fset, astFile); err != nil {
-				return nil, err
-			}
+	var known bool
+	if dest == nil {
+		known, ok = detector.literal(basicList)
+	} else {
+		known, ok = detector.object(dest)
+	}
+	if ok {
+		reportSQL(pass, basicList, sql, known, conf)
+	}
+}
 
-			// Overwrite the original file with the new code
-			filename := pass.Fset.File(astFile.Pos()).Name()
-			if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
-				return nil, err
+// reportSQL reports the sql literal that is not formatted, the fix replaces only the literal.
+// when the literal is not known to be sql, the literal that can not be parsed is ignored
+func reportSQL(pass *analysis.Pass, basicList *ast.BasicLit, sql string, known bool, conf *fmtconf.Config) {
+	result, err := formatter.Format(sql, conf)
+	if err != nil {
+		var lossless *formatter.LosslessError
+		if known || errors.As(err, &lossless) {
+			pass.Reportf(basicList.Pos(), "sql can not be formatted: %s", err.Error())
+		}
+		return
+	}
+
+	formatted := fmt.Sprintf("`%s`", result)
+	if strings.Contains(result, "`") {
+		formatted = strconv.Quote(result)
+	}
+	if formatted == basicList.Value {
+		return
+	}
+
+	pass.Report(analysis.Diagnostic{
+		Pos:     basicList.Pos(),
+		End:     basicList.End(),
+		Message: "sql is not formatted",
+		SuggestedFixes: []analysis.SuggestedFix{
+			{
+				Message: "format sql",
+				TextEdits: []analysis.TextEdit{
+					{
+						Pos:     basicList.Pos(),
+						End:     basicList.End(),
+						NewText: []byte(formatted),
+					},
+				},
+			},
+		},
+	})
+}
+
+// sqlPrefixes are the first words of the statements that prefix detection formats
+var sqlPrefixes = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "VALUES"}
+
+// hasSQLPrefix reports whether sql starts with a statement keyword after the leading comments
+// ex) "-- list users\nSELECT ...", "/* cte */ WITH ...", "VALUES (1)"
+func hasSQLPrefix(sql string) bool {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			end := strings.Index(sql, "\n")
+			if end < 0 {
+				return false
 			}
+			sql = sql[end+1:]
+			continue
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return false
+			}
+			sql = sql[end+2:]
+			continue
 		}
+		break
+	}
 
+	upperSQL := strings.ToUpper(sql)
+	for _, prefix := range sqlPrefixes {
+		// ex) SELECT(, SELECT\n, not SELECTION
+		if strings.HasPrefix(upperSQL, prefix) && (len(sql) == len(prefix) || !isWordChar(sql[len(prefix)])) {
+			return true
+		}
 	}
-	return nil, nil
+	return false
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isQueryMethodCall(call *ast.CallExpr, conf *fmtconf.Config) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && conf.IsQueryMethod(sel.Sel.Name)
+}
+
+func isConst(pass *analysis.Pass, expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = pass.TypesInfo.ObjectOf(ident).(*types.Const)
+	return ok
 }
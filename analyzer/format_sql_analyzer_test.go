@@ -0,0 +1,39 @@
+package analyzer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Toru-Takagi/gopsqlfmt/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestFormatSQLAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzer.FormatSQLAnalyzer, "prefix", "directive", "ignorefile", "generated")
+}
+
+func TestFormatSQLAnalyzerDetectionTypeTypes(t *testing.T) {
+	testdata := analysistest.TestData()
+	// .gopsqlfmt.yaml is read from the working directory
+	chdir(t, filepath.Join(testdata, "types"))
+
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.FormatSQLAnalyzer, "a", "sites", "queryfunc/...")
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
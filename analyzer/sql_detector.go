@@ -0,0 +1,187 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// queryFuncFact is exported for the funcs that pass their Arg-th parameter to the sql parameter of a query func,
+// the packages that import the func find the sql passed to it.
+// ex) func (r *Repo) get(ctx context.Context, query string) { r.db.QueryRowContext(ctx, query) }
+type queryFuncFact struct {
+	Arg   int
+	Known bool
+}
+
+func (*queryFuncFact) AFact() {}
+
+func (f *queryFuncFact) String() string {
+	return fmt.Sprintf("queryFunc(%d)", f.Arg)
+}
+
+// param is the Index-th parameter of Func
+type param struct {
+	Func  *types.Func
+	Index int
+}
+
+// sqlDetector finds the strings that flow into the sql parameter of the query funcs
+type sqlDetector struct {
+	pass *analysis.Pass
+	conf *fmtconf.Config
+	// the value is true when the sql parameter is known by the query funcs,
+	// false when it is guessed by the query method name
+	literals map[*ast.BasicLit]bool
+	objects  map[types.Object]bool
+	// params are the parameters of the funcs declared in the package, funcs are the funcs that pass one of them to a query func
+	params map[*types.Var]param
+	funcs  map[*types.Func]*queryFuncFact
+}
+
+func newSQLDetector(pass *analysis.Pass, conf *fmtconf.Config) *sqlDetector {
+	d := &sqlDetector{
+		pass:     pass,
+		conf:     conf,
+		literals: map[*ast.BasicLit]bool{},
+		objects:  map[types.Object]bool{},
+		params:   map[*types.Var]param{},
+		funcs:    map[*types.Func]*queryFuncFact{},
+	}
+
+	for _, astFile := range pass.Files {
+		for _, decl := range astFile.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				d.addParams(fd)
+			}
+		}
+	}
+
+	// a func of the package that becomes a query func makes its callers query func calls, repeat until no func is added
+	for changed := true; changed; {
+		changed = false
+		for _, astFile := range pass.Files {
+			ast.Inspect(astFile, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if arg, known, ok := d.queryArg(call); ok && arg < len(call.Args) {
+					changed = d.mark(call.Args[arg], known) || changed
+				}
+				return true
+			})
+		}
+	}
+
+	for fn, fact := range d.funcs {
+		pass.ExportObjectFact(fn, fact)
+	}
+	return d
+}
+
+func (d *sqlDetector) addParams(fd *ast.FuncDecl) {
+	fn, ok := d.pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		d.params[params.At(i)] = param{Func: fn, Index: i}
+	}
+}
+
+// queryArg returns the index of the sql argument of call
+func (d *sqlDetector) queryArg(call *ast.CallExpr) (arg int, known bool, ok bool) {
+	fn, ok := typeutil.Callee(d.pass.TypesInfo, call).(*types.Func)
+	if !ok {
+		return 0, false, false
+	}
+	if arg, ok := d.conf.QueryFuncArg(fn.FullName()); ok {
+		return arg, true, true
+	}
+	// ex) the funcs of the package or the imported packages that wrap a query func
+	fn = fn.Origin()
+	if fact, ok := d.funcs[fn]; ok {
+		return fact.Arg, fact.Known, true
+	}
+	var fact queryFuncFact
+	if d.pass.ImportObjectFact(fn, &fact) {
+		return fact.Arg, fact.Known, true
+	}
+
+	// ex) the methods of sqlc DBTX, the first string parameter is sql
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil || !d.conf.IsQueryMethod(fn.Name()) {
+		return 0, false, false
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if basic, ok := sig.Params().At(i).Type().Underlying().(*types.Basic); ok && basic.Kind() == types.String {
+			return i, false, true
+		}
+	}
+	return 0, false, false
+}
+
+// mark records the const, variable, field or literal expr passed to a query func,
+// it reports whether a func of the package is found to pass its parameter to a query func
+func (d *sqlDetector) mark(expr ast.Expr, known bool) bool {
+	var obj types.Object
+	switch x := ast.Unparen(expr).(type) {
+	case *ast.BasicLit:
+		d.literals[x] = d.literals[x] || known
+		return false
+	case *ast.Ident:
+		// ex) db.QueryContext(ctx, query)
+		obj = d.pass.TypesInfo.ObjectOf(x)
+	case *ast.SelectorExpr:
+		// ex) db.QueryContext(ctx, q.SQL), db.QueryContext(ctx, queries.GetUser)
+		obj = d.pass.TypesInfo.ObjectOf(x.Sel)
+	}
+
+	switch obj.(type) {
+	case *types.Const, *types.Var:
+		d.objects[obj] = d.objects[obj] || known
+	}
+
+	// ex) func get(ctx context.Context, query string) { db.QueryRowContext(ctx, query) }
+	v, ok := obj.(*types.Var)
+	if !ok {
+		return false
+	}
+	p, ok := d.params[v]
+	if !ok {
+		return false
+	}
+	if fact, ok := d.funcs[p.Func]; ok && (fact.Known || !known) {
+		return false
+	}
+	d.funcs[p.Func] = &queryFuncFact{Arg: p.Index, Known: known}
+	return true
+}
+
+// literal reports whether lit is passed to a query func directly
+func (d *sqlDetector) literal(lit *ast.BasicLit) (known bool, ok bool) {
+	known, ok = d.literals[lit]
+	return known, ok
+}
+
+// object reports whether the value of the const, variable or field expr is passed to a query func
+func (d *sqlDetector) object(expr ast.Expr) (known bool, ok bool) {
+	var obj types.Object
+	switch x := expr.(type) {
+	case *ast.Ident:
+		obj = d.pass.TypesInfo.ObjectOf(x)
+	case *ast.SelectorExpr:
+		obj = d.pass.TypesInfo.ObjectOf(x.Sel)
+	}
+	if obj == nil {
+		return false, false
+	}
+	known, ok = d.objects[obj]
+	return known, ok
+}
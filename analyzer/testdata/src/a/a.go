@@ -0,0 +1,33 @@
+package a
+
+import (
+	"context"
+	"database/sql"
+)
+
+const formatted = `
+SELECT
+  user_name
+FROM users
+`
+
+const unformatted = `select user_name from users` // want "sql is not formatted"
+
+const withQuery = `with t as (select user_name from users) select user_name from t` // want "sql is not formatted"
+
+const commented = /* want "sql is not formatted" */ `-- name: ListUsers :many
+select user_name from users`
+
+const notSQL = "update the record"
+
+const invalid = `select from where` // want "sql can not be formatted"
+
+func run(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, formatted)
+	db.QueryContext(ctx, unformatted)
+	db.QueryContext(ctx, withQuery)
+	db.QueryContext(ctx, (commented))
+	db.ExecContext(ctx, invalid)
+	db.Exec(`values (1)`) // want "sql is not formatted"
+	_ = notSQL
+}
@@ -0,0 +1,47 @@
+package directive
+
+import (
+	"context"
+	"database/sql"
+)
+
+//gopsqlfmt:ignore
+const ignored = `select user_name from users`
+
+const (
+	//gopsqlfmt:ignore
+	ignoredSpec = `select user_name from users`
+	formatSpec  = `select user_name from users` // want "sql is not formatted"
+)
+
+const ignoredTrailing = `select user_name from users` //gopsqlfmt:ignore
+
+//gopsqlfmt:format
+const forced = `values (1)` // want "sql is not formatted"
+
+//gopsqlfmt:indent=tab
+const tabIndent = `select user_name from users` // want "sql is not formatted"
+
+//gopsqlfmt:keyword-case=lower
+const lowerKeyword = `SELECT user_name FROM users` // want "sql is not formatted"
+
+//gopsqlfmt:indent=four // want "unknown gopsqlfmt directive"
+const unknown = `select user_name from users` // want "sql is not formatted"
+
+//gopsqlfmt:ignore
+func ignoredFunc(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, `select user_name from users`)
+}
+
+func run(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, ignored)
+	db.QueryContext(ctx, ignoredSpec)
+	db.QueryContext(ctx, formatSpec)
+	db.QueryContext(ctx, ignoredTrailing)
+	db.QueryContext(ctx, tabIndent)
+	db.QueryContext(ctx, lowerKeyword)
+	db.QueryContext(ctx, unknown)
+
+	//gopsqlfmt:ignore
+	db.QueryContext(ctx, `select user_name from users`)
+}
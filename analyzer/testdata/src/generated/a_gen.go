@@ -0,0 +1,3 @@
+package generated
+
+const genQuery = `select user_name from users`
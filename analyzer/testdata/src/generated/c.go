@@ -0,0 +1,14 @@
+package generated
+
+import (
+	"context"
+	"database/sql"
+)
+
+const query = `select user_name from users` // want "sql is not formatted"
+
+func run(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, genQuery)
+	db.QueryContext(ctx, sqlcQuery)
+	db.QueryContext(ctx, query)
+}
@@ -0,0 +1,14 @@
+//gopsqlfmt:ignore-file
+
+package ignorefile
+
+import (
+	"context"
+	"database/sql"
+)
+
+const query = `select user_name from users`
+
+func run(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, query)
+}
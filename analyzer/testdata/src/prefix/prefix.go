@@ -0,0 +1,45 @@
+package prefix
+
+import (
+	"context"
+	"database/sql"
+)
+
+const unformatted = `select user_name from users` // want "sql is not formatted"
+
+const notSQL = "hello"
+
+// strings that start with a keyword are not reported unless they are consts
+var hint = "Update available"
+
+// sql that can be parsed is formatted at any site
+var listUsers = "select id from users" // want "sql is not formatted"
+
+type Button struct {
+	Label string
+}
+
+// not passed to a query func
+const notPassed = `delete from users` // want "sql is not formatted"
+
+const withCTE = `with u as (select id from users) select id from u` // want "sql is not formatted"
+
+const commented = "-- list users\nselect id from users" // want "sql is not formatted"
+
+const values = "values (1)" // want "sql is not formatted"
+
+// not a statement keyword
+const selection = "selection"
+
+func run(ctx context.Context, db *sql.DB) {
+	db.QueryContext(ctx, unformatted)
+
+	// not a prefix of the sql statements
+	db.Exec(`show search_path`)
+
+	_ = notSQL
+
+	title := "Select a plan to continue"
+	_ = Button{Label: "Delete account"}
+	_ = title
+}
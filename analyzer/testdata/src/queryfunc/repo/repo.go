@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"context"
+
+	"queryfunc/store"
+)
+
+const getUser = `select user_name from users where user_uuid = $1` // want "sql is not formatted"
+
+func run(ctx context.Context, s *store.Store) {
+	var name string
+	s.Get(ctx, &name, getUser, 1)
+
+	s.List(ctx, `select user_name from users`) // want "sql is not formatted"
+
+	s.Log(`select user_name from users`)
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+// Get passes query to a query func, the callers in the other packages are detected by the fact
+func (s *Store) Get(ctx context.Context, dest any, query string, args ...any) error { // want Get:"queryFunc\\(2\\)"
+	return s.db.QueryRowContext(ctx, query, args...).Scan(dest)
+}
+
+// List passes query to Get
+func (s *Store) List(ctx context.Context, query string) error { // want List:"queryFunc\\(1\\)"
+	return s.Get(ctx, nil, query)
+}
+
+// Log does not pass msg to a query func
+func (s *Store) Log(msg string) {}
@@ -0,0 +1,37 @@
+package sites
+
+import "context"
+
+// DB is the interface generated by sqlc
+type DB interface {
+	QueryContext(ctx context.Context, query string, args ...any) error
+}
+
+type Query struct {
+	SQL string
+}
+
+func log(s string) {}
+
+var packageVar = `select user_name from users` // want "sql is not formatted"
+
+var key = "users"
+
+func run(ctx context.Context, db DB) {
+	db.QueryContext(ctx, packageVar)
+
+	q := "select user_name from users" // want "sql is not formatted"
+	q = `select user_age from users`   // want "sql is not formatted"
+	db.QueryContext(ctx, q)
+
+	query := Query{SQL: `select user_uuid from users`} // want "sql is not formatted"
+	db.QueryContext(ctx, query.SQL)
+
+	db.QueryContext(ctx, `delete from users where user_uuid = $1`, 1) // want "sql is not formatted"
+
+	// not sql
+	db.QueryContext(ctx, key)
+
+	// not a query method
+	log(`select user_name from users`)
+}
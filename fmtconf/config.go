@@ -9,20 +9,52 @@ const (
 
 type Config struct {
 	IndentType     IndentType
+	KeywordCase    KeywordCase
+	TypeCastStyle  TypeCastStyle
 	FuncCallConfig FuncCallConfig
+	Identifier     IdentifierConfig
 	Join           JoinConfig
+	Placeholder    PlaceholderConfig
+	Statement      StatementConfig
+	Target         TargetConfig
 }
 
 func NewDefaultConfig() *Config {
 	return &Config{
-		IndentType: INDENT_TYPE_TWO_SPACES,
+		IndentType:    INDENT_TYPE_TWO_SPACES,
+		KeywordCase:   KEYWORD_CASE_UPPER,
+		TypeCastStyle: TYPE_CAST_STYLE_DOUBLE_COLON,
 		FuncCallConfig: FuncCallConfig{
 			FuncNameTypeCase: FUNC_NAME_TYPE_CASE_LOWER,
 		},
+		Identifier: IdentifierConfig{
+			Case:  IDENTIFIER_CASE_LOWER,
+			Quote: QUOTE_IDENTIFIERS_AS_NEEDED,
+		},
 		Join: JoinConfig{
 			StartIndentType: JOIN_START_INDENT_TYPE_ONE_SPACE,
 			LineBreakType:   JOIN_LINE_BREAK_ON_CLAUSE,
 		},
+		Placeholder: PlaceholderConfig{
+			Dialects: []PlaceholderDialect{PLACEHOLDER_DIALECT_COLON},
+		},
+		Statement: StatementConfig{
+			BlankLines: 1,
+			Semicolon:  STATEMENT_SEMICOLON_AS_NEEDED,
+		},
+		Target: TargetConfig{
+			Exclude: []string{"*_gen.go"},
+			Sites: []TargetSite{
+				TARGET_SITE_CONST,
+				TARGET_SITE_VAR,
+				TARGET_SITE_ASSIGN,
+				TARGET_SITE_STRUCT_FIELD,
+				TARGET_SITE_FUNC_ARG,
+			},
+			DetectionType: DETECTION_TYPE_PREFIX,
+			QueryFuncs:    append([]QueryFunc{}, DefaultQueryFuncs...),
+			QueryMethods:  append([]string{}, DefaultQueryMethods...),
+		},
 	}
 }
 
@@ -0,0 +1,67 @@
+package fmtconf
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IsTargetFile reports whether the sql strings in the file are formatted,
+// the patterns are matched against the path relative to the current directory
+func (c *Config) IsTargetFile(filename string) bool {
+	path := filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filename); err == nil {
+			path = rel
+		}
+	}
+	path = filepath.ToSlash(path)
+
+	if len(c.Target.Include) > 0 && !matchAnyPattern(c.Target.Include, path) {
+		return false
+	}
+	return !matchAnyPattern(c.Target.Exclude, path)
+}
+
+func matchAnyPattern(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a glob pattern, ** matches any number of directories.
+// a pattern without / is matched against the file name, ex) *_gen.go
+func matchPattern(pattern, path string) bool {
+	if !strings.Contains(pattern, "/") {
+		path = path[strings.LastIndex(path, "/")+1:]
+	}
+
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				re.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	re.WriteString("$")
+
+	matched, err := regexp.MatchString(re.String(), path)
+	return err == nil && matched
+}
@@ -0,0 +1,34 @@
+package fmtconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{name: "file name", pattern: "*_gen.go", path: "internal/db/query_gen.go", want: true},
+		{name: "file name not match", pattern: "*_gen.go", path: "internal/db/query.go", want: false},
+		{name: "double star", pattern: "internal/**/*.go", path: "internal/db/query/user.go", want: true},
+		{name: "double star zero directory", pattern: "internal/**/*.go", path: "internal/user.go", want: true},
+		{name: "double star not match", pattern: "internal/**/*.go", path: "cmd/main.go", want: false},
+		{name: "single star does not match slash", pattern: "internal/*.go", path: "internal/db/user.go", want: false},
+		{name: "question", pattern: "db/?.go", path: "db/a.go", want: true},
+		{name: "dot is not any character", pattern: "db/a.go", path: "db/abgo", want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, matchPattern(tt.pattern, tt.path))
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package fmtconf
 
+import "strings"
+
 type FuncNameTypeCase string
 
 const (
@@ -9,9 +11,28 @@ const (
 
 type FuncCallConfig struct {
 	FuncNameTypeCase
+	// Names are user defined functions whose names are converted like the built-in functions, ex) my_func, myschema.my_func
+	Names []string
 }
 
 func (c *Config) WithFuncNameTypeCaseUpper() *Config {
 	c.FuncCallConfig.FuncNameTypeCase = FUNC_NAME_TYPE_CASE_UPPER
 	return c
 }
+
+func (c *Config) WithFuncNames(names ...string) *Config {
+	c.FuncCallConfig.Names = append(c.FuncCallConfig.Names, names...)
+	return c
+}
+
+// IsFuncName reports whether the function is a user defined function of Names.
+// A name without schema matches the function of any schema.
+func (c *Config) IsFuncName(schema, name string) bool {
+	for _, n := range c.FuncCallConfig.Names {
+		n = strings.ToLower(n)
+		if n == strings.ToLower(name) || (schema != "" && n == strings.ToLower(schema+"."+name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package fmtconf
+
+type IdentifierCase string
+
+const (
+	// IDENTIFIER_CASE_LOWER outputs the identifiers without quotes in lower case, as PostgreSQL folds them
+	IDENTIFIER_CASE_LOWER IdentifierCase = "LOWER"
+	// IDENTIFIER_CASE_PRESERVE keeps the case of the identifiers without quotes written in the input sql
+	IDENTIFIER_CASE_PRESERVE IdentifierCase = "PRESERVE"
+)
+
+type QuoteIdentifiersType string
+
+const (
+	// QUOTE_IDENTIFIERS_AS_NEEDED quotes the identifiers that are upper case, reserved keywords or contain special characters
+	QUOTE_IDENTIFIERS_AS_NEEDED QuoteIdentifiersType = "AS_NEEDED"
+	QUOTE_IDENTIFIERS_ALWAYS    QuoteIdentifiersType = "ALWAYS"
+)
+
+type IdentifierConfig struct {
+	Case  IdentifierCase
+	Quote QuoteIdentifiersType
+}
+
+func (c *Config) WithIdentifierCasePreserve() *Config {
+	c.Identifier.Case = IDENTIFIER_CASE_PRESERVE
+	return c
+}
+
+func (c *Config) WithQuoteIdentifiersAlways() *Config {
+	c.Identifier.Quote = QUOTE_IDENTIFIERS_ALWAYS
+	return c
+}
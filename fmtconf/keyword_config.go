@@ -0,0 +1,20 @@
+package fmtconf
+
+type KeywordCase string
+
+const (
+	KEYWORD_CASE_UPPER KeywordCase = "UPPER"
+	KEYWORD_CASE_LOWER KeywordCase = "LOWER"
+	// KEYWORD_CASE_PRESERVE keeps the case of the keywords written in the input sql
+	KEYWORD_CASE_PRESERVE KeywordCase = "PRESERVE"
+)
+
+func (c *Config) WithKeywordCaseLower() *Config {
+	c.KeywordCase = KEYWORD_CASE_LOWER
+	return c
+}
+
+func (c *Config) WithKeywordCasePreserve() *Config {
+	c.KeywordCase = KEYWORD_CASE_PRESERVE
+	return c
+}
@@ -0,0 +1,37 @@
+package fmtconf
+
+type PlaceholderDialect string
+
+const (
+	// PLACEHOLDER_DIALECT_COLON is the named parameter of sqlx, ex) :user_uuid
+	PLACEHOLDER_DIALECT_COLON PlaceholderDialect = "COLON"
+	// PLACEHOLDER_DIALECT_AT is the named argument of pgx, ex) @user_uuid
+	PLACEHOLDER_DIALECT_AT PlaceholderDialect = "AT"
+	// PLACEHOLDER_DIALECT_QUESTION is the bind variable rebound by sqlx, ex) ?
+	// The jsonb operators that contain ? can not be used with it.
+	PLACEHOLDER_DIALECT_QUESTION PlaceholderDialect = "QUESTION"
+	// PLACEHOLDER_DIALECT_SQLC is the macro of sqlc, ex) sqlc.arg(user_uuid), sqlc.narg(name), sqlc.slice(ids)
+	PLACEHOLDER_DIALECT_SQLC PlaceholderDialect = "SQLC"
+	// PLACEHOLDER_DIALECT_TEMPLATE is the action of text/template, ex) {{.UserUUID}}
+	PLACEHOLDER_DIALECT_TEMPLATE PlaceholderDialect = "TEMPLATE"
+)
+
+type PlaceholderConfig struct {
+	// Dialects are the placeholders that are kept as written
+	Dialects []PlaceholderDialect
+}
+
+// WithPlaceholderDialects replaces the placeholder dialects
+func (c *Config) WithPlaceholderDialects(dialects ...PlaceholderDialect) *Config {
+	c.Placeholder.Dialects = dialects
+	return c
+}
+
+func (c *Config) HasPlaceholderDialect(dialect PlaceholderDialect) bool {
+	for _, d := range c.Placeholder.Dialects {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package fmtconf
+
+type StatementSemicolonType string
+
+const (
+	// STATEMENT_SEMICOLON_AS_NEEDED terminates the statements with a semicolon when there are multiple statements or the input has it
+	STATEMENT_SEMICOLON_AS_NEEDED StatementSemicolonType = "AS_NEEDED"
+	STATEMENT_SEMICOLON_ALWAYS    StatementSemicolonType = "ALWAYS"
+)
+
+type StatementConfig struct {
+	// BlankLines is the number of blank lines between statements
+	BlankLines int
+	Semicolon  StatementSemicolonType
+}
+
+func (c *Config) WithStatementBlankLines(n int) *Config {
+	c.Statement.BlankLines = n
+	return c
+}
+
+func (c *Config) WithStatementSemicolonAlways() *Config {
+	c.Statement.Semicolon = STATEMENT_SEMICOLON_ALWAYS
+	return c
+}
@@ -0,0 +1,188 @@
+package fmtconf
+
+type (
+	TargetSite    string
+	DetectionType string
+)
+
+const (
+	TARGET_SITE_CONST        TargetSite = "CONST"
+	TARGET_SITE_VAR          TargetSite = "VAR"
+	TARGET_SITE_ASSIGN       TargetSite = "ASSIGN"
+	TARGET_SITE_STRUCT_FIELD TargetSite = "STRUCT_FIELD"
+	TARGET_SITE_FUNC_ARG     TargetSite = "FUNC_ARG"
+
+	// DETECTION_TYPE_TYPES formats the strings that flow into the sql parameter of the query funcs,
+	// a const or variable of another package is followed only when the package passes it to a query func
+	DETECTION_TYPE_TYPES DetectionType = "TYPES"
+	// DETECTION_TYPE_PREFIX formats the strings that start with SELECT, INSERT, UPDATE or DELETE
+	DETECTION_TYPE_PREFIX DetectionType = "PREFIX"
+)
+
+// QueryFunc is a func that takes sql as the Arg-th argument
+type QueryFunc struct {
+	// Name is the full name of the func, ex) (*database/sql.DB).QueryContext, github.com/jmoiron/sqlx.NamedExec
+	Name string `yaml:"name"`
+	Arg  int    `yaml:"arg"`
+}
+
+// DefaultQueryFuncs are the funcs of database/sql, sqlx and pgx that take sql as an argument
+var DefaultQueryFuncs = concatQueryFuncs(
+	methodQueryFuncs([]string{"*database/sql.DB", "*database/sql.Tx", "*database/sql.Conn"}, map[string]int{
+		"Exec": 0, "ExecContext": 1,
+		"Query": 0, "QueryContext": 1,
+		"QueryRow": 0, "QueryRowContext": 1,
+		"Prepare": 0, "PrepareContext": 1,
+	}),
+	methodQueryFuncs([]string{"*github.com/jmoiron/sqlx.DB", "*github.com/jmoiron/sqlx.Tx", "*github.com/jmoiron/sqlx.Conn"}, map[string]int{
+		"Get": 1, "GetContext": 2,
+		"Select": 1, "SelectContext": 2,
+		"Queryx": 0, "QueryxContext": 1,
+		"QueryRowx": 0, "QueryRowxContext": 1,
+		"MustExec": 0, "MustExecContext": 1,
+		"Preparex": 0, "PreparexContext": 1,
+		"NamedExec": 0, "NamedExecContext": 1,
+		"NamedQuery": 0, "NamedQueryContext": 1,
+		"PrepareNamed": 0, "PrepareNamedContext": 1,
+		"Rebind": 0,
+	}),
+	[]QueryFunc{
+		{Name: "github.com/jmoiron/sqlx.Get", Arg: 2},
+		{Name: "github.com/jmoiron/sqlx.GetContext", Arg: 3},
+		{Name: "github.com/jmoiron/sqlx.Select", Arg: 2},
+		{Name: "github.com/jmoiron/sqlx.SelectContext", Arg: 3},
+		{Name: "github.com/jmoiron/sqlx.NamedExec", Arg: 1},
+		{Name: "github.com/jmoiron/sqlx.NamedExecContext", Arg: 2},
+		{Name: "github.com/jmoiron/sqlx.NamedQuery", Arg: 1},
+		{Name: "github.com/jmoiron/sqlx.NamedQueryContext", Arg: 2},
+		{Name: "github.com/jmoiron/sqlx.MustExec", Arg: 1},
+		{Name: "github.com/jmoiron/sqlx.MustExecContext", Arg: 2},
+	},
+	methodQueryFuncs([]string{
+		"*github.com/jackc/pgx/v4.Conn", "github.com/jackc/pgx/v4.Tx", "*github.com/jackc/pgx/v4/pgxpool.Pool", "*github.com/jackc/pgx/v4/pgxpool.Conn", "*github.com/jackc/pgx/v4/pgxpool.Tx",
+		"*github.com/jackc/pgx/v5.Conn", "github.com/jackc/pgx/v5.Tx", "*github.com/jackc/pgx/v5/pgxpool.Pool", "*github.com/jackc/pgx/v5/pgxpool.Conn", "*github.com/jackc/pgx/v5/pgxpool.Tx",
+	}, map[string]int{
+		"Exec":     1,
+		"Query":    1,
+		"QueryRow": 1,
+		"Prepare":  2,
+	}),
+	methodQueryFuncs([]string{"*github.com/jackc/pgx/v4.Batch", "*github.com/jackc/pgx/v5.Batch"}, map[string]int{
+		"Queue": 0,
+	}),
+)
+
+func methodQueryFuncs(recvs []string, methods map[string]int) []QueryFunc {
+	var funcs []QueryFunc
+	for _, recv := range recvs {
+		for method, arg := range methods {
+			funcs = append(funcs, QueryFunc{Name: "(" + recv + ")." + method, Arg: arg})
+		}
+	}
+	return funcs
+}
+
+func concatQueryFuncs(lists ...[]QueryFunc) []QueryFunc {
+	var funcs []QueryFunc
+	for _, list := range lists {
+		funcs = append(funcs, list...)
+	}
+	return funcs
+}
+
+// DefaultQueryMethods are the method names of database/sql, sqlx, pgx and sqlc DBTX that take sql as an argument,
+// they are used for the methods that are not in the query funcs, ex) the methods of sqlc DBTX
+var DefaultQueryMethods = []string{
+	// database/sql, sqlc DBTX
+	"Exec", "ExecContext",
+	"Query", "QueryContext",
+	"QueryRow", "QueryRowContext",
+	"Prepare", "PrepareContext",
+	// sqlx
+	"Get", "GetContext",
+	"Select", "SelectContext",
+	"Queryx", "QueryxContext",
+	"QueryRowx", "QueryRowxContext",
+	"MustExec", "MustExecContext",
+	"Preparex", "PreparexContext",
+	"NamedExec", "NamedExecContext",
+	"NamedQuery", "NamedQueryContext",
+	"PrepareNamed", "PrepareNamedContext",
+	// pgx
+	"Queue",
+}
+
+type TargetConfig struct {
+	// Include and Exclude are glob patterns of the files, ex) internal/**/*.go, *_gen.go
+	Include       []string
+	Exclude       []string
+	Sites         []TargetSite
+	DetectionType DetectionType
+	QueryFuncs    []QueryFunc
+	QueryMethods  []string
+}
+
+func (c *Config) WithTargetSites(sites ...TargetSite) *Config {
+	c.Target.Sites = sites
+	return c
+}
+
+func (c *Config) WithInclude(patterns ...string) *Config {
+	c.Target.Include = append(c.Target.Include, patterns...)
+	return c
+}
+
+func (c *Config) WithExclude(patterns ...string) *Config {
+	c.Target.Exclude = append(c.Target.Exclude, patterns...)
+	return c
+}
+
+func (c *Config) WithDetectionTypePrefix() *Config {
+	c.Target.DetectionType = DETECTION_TYPE_PREFIX
+	return c
+}
+
+func (c *Config) WithDetectionTypeTypes() *Config {
+	c.Target.DetectionType = DETECTION_TYPE_TYPES
+	return c
+}
+
+func (c *Config) WithQueryFuncs(funcs ...QueryFunc) *Config {
+	c.Target.QueryFuncs = append(c.Target.QueryFuncs, funcs...)
+	return c
+}
+
+func (c *Config) WithQueryMethods(methods ...string) *Config {
+	c.Target.QueryMethods = append(c.Target.QueryMethods, methods...)
+	return c
+}
+
+// IsTargetSite reports whether the sql strings at site are formatted
+func (c *Config) IsTargetSite(site TargetSite) bool {
+	for _, s := range c.Target.Sites {
+		if s == site {
+			return true
+		}
+	}
+	return false
+}
+
+// IsQueryMethod reports whether name is a method that takes sql as an argument
+func (c *Config) IsQueryMethod(name string) bool {
+	for _, m := range c.Target.QueryMethods {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryFuncArg returns the index of the sql argument of the func named name
+func (c *Config) QueryFuncArg(name string) (int, bool) {
+	for _, f := range c.Target.QueryFuncs {
+		if f.Name == name {
+			return f.Arg, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,15 @@
+package fmtconf
+
+type TypeCastStyle string
+
+const (
+	// TYPE_CAST_STYLE_DOUBLE_COLON outputs type casts as created_at::date
+	TYPE_CAST_STYLE_DOUBLE_COLON TypeCastStyle = "DOUBLE_COLON"
+	// TYPE_CAST_STYLE_CAST outputs type casts as CAST(created_at AS date)
+	TYPE_CAST_STYLE_CAST TypeCastStyle = "CAST"
+)
+
+func (c *Config) WithTypeCastStyleCast() *Config {
+	c.TypeCastStyle = TYPE_CAST_STYLE_CAST
+	return c
+}
@@ -1,13 +1,16 @@
 package fmtconf
 
 import (
+	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type YamlFuncSettings struct {
 	NameTypeCase FuncNameTypeCase `yaml:"name-type-case"`
+	Names        []string         `yaml:"names"`
 }
 
 type YamlJoinSettings struct {
@@ -15,14 +18,39 @@ type YamlJoinSettings struct {
 	LineBreakType   JoinConfigLineBreakType   `yaml:"line-break-type"`
 }
 
+type YamlPlaceholderSettings struct {
+	Dialects []PlaceholderDialect `yaml:"dialects"`
+}
+
+type YamlStatementSettings struct {
+	BlankLines *int                   `yaml:"blank-lines"`
+	Semicolon  StatementSemicolonType `yaml:"semicolon"`
+}
+
 type YamlFormatSettings struct {
-	IndentType IndentType       `yaml:"indent-type"`
-	Func       YamlFuncSettings `yaml:"func"`
-	Join       YamlJoinSettings `yaml:"join"`
+	IndentType       IndentType              `yaml:"indent-type"`
+	KeywordCase      KeywordCase             `yaml:"keyword-case"`
+	IdentifierCase   IdentifierCase          `yaml:"identifier-case"`
+	QuoteIdentifiers QuoteIdentifiersType    `yaml:"quote-identifiers"`
+	TypeCastStyle    TypeCastStyle           `yaml:"type-cast-style"`
+	Func             YamlFuncSettings        `yaml:"func"`
+	Join             YamlJoinSettings        `yaml:"join"`
+	Placeholder      YamlPlaceholderSettings `yaml:"placeholder"`
+	Statement        YamlStatementSettings   `yaml:"statement"`
+}
+
+type YamlTargetSettings struct {
+	Include       []string      `yaml:"include"`
+	Exclude       []string      `yaml:"exclude"`
+	Sites         []TargetSite  `yaml:"sites"`
+	DetectionType DetectionType `yaml:"detection-type"`
+	QueryFuncs    []QueryFunc   `yaml:"query-funcs"`
+	QueryMethods  []string      `yaml:"query-methods"`
 }
 
 type YamlConfig struct {
 	FormatSettings YamlFormatSettings `yaml:"format-settings"`
+	TargetSettings YamlTargetSettings `yaml:"target-settings"`
 }
 
 func LoadYamlConfig() (*Config, error) {
@@ -36,10 +64,43 @@ func LoadYamlConfig() (*Config, error) {
 				conf.IndentType = INDENT_TYPE_TAB
 			}
 
+			switch keywordCase := KeywordCase(normalizeYamlValue(string(ymlconf.FormatSettings.KeywordCase))); keywordCase {
+			case "":
+			case KEYWORD_CASE_UPPER, KEYWORD_CASE_LOWER, KEYWORD_CASE_PRESERVE:
+				conf.KeywordCase = keywordCase
+			default:
+				return nil, fmt.Errorf("unknown keyword-case: %s", ymlconf.FormatSettings.KeywordCase)
+			}
+
+			switch typeCastStyle := TypeCastStyle(normalizeYamlValue(string(ymlconf.FormatSettings.TypeCastStyle))); typeCastStyle {
+			case "":
+			case TYPE_CAST_STYLE_DOUBLE_COLON, TYPE_CAST_STYLE_CAST:
+				conf.TypeCastStyle = typeCastStyle
+			default:
+				return nil, fmt.Errorf("unknown type-cast-style: %s", ymlconf.FormatSettings.TypeCastStyle)
+			}
+
 			switch ymlconf.FormatSettings.Func.NameTypeCase {
 			case FUNC_NAME_TYPE_CASE_UPPER:
 				conf.FuncCallConfig.FuncNameTypeCase = FUNC_NAME_TYPE_CASE_UPPER
 			}
+			conf.FuncCallConfig.Names = append(conf.FuncCallConfig.Names, ymlconf.FormatSettings.Func.Names...)
+
+			switch identifierCase := IdentifierCase(normalizeYamlValue(string(ymlconf.FormatSettings.IdentifierCase))); identifierCase {
+			case "":
+			case IDENTIFIER_CASE_LOWER, IDENTIFIER_CASE_PRESERVE:
+				conf.Identifier.Case = identifierCase
+			default:
+				return nil, fmt.Errorf("unknown identifier-case: %s", ymlconf.FormatSettings.IdentifierCase)
+			}
+
+			switch quote := QuoteIdentifiersType(normalizeYamlValue(string(ymlconf.FormatSettings.QuoteIdentifiers))); quote {
+			case "":
+			case QUOTE_IDENTIFIERS_AS_NEEDED, QUOTE_IDENTIFIERS_ALWAYS:
+				conf.Identifier.Quote = quote
+			default:
+				return nil, fmt.Errorf("unknown quote-identifiers: %s", ymlconf.FormatSettings.QuoteIdentifiers)
+			}
 
 			switch ymlconf.FormatSettings.Join.StartIndentType {
 			case JOIN_START_INDENT_TYPE_NONE:
@@ -50,8 +111,61 @@ func LoadYamlConfig() (*Config, error) {
 			case JOIN_LINE_BREAK_OFF:
 				conf.Join.LineBreakType = JOIN_LINE_BREAK_OFF
 			}
+
+			if dialects := ymlconf.FormatSettings.Placeholder.Dialects; len(dialects) > 0 {
+				conf.Placeholder.Dialects = nil
+				for _, d := range dialects {
+					switch dialect := PlaceholderDialect(normalizeYamlValue(string(d))); dialect {
+					case PLACEHOLDER_DIALECT_COLON, PLACEHOLDER_DIALECT_AT, PLACEHOLDER_DIALECT_QUESTION, PLACEHOLDER_DIALECT_SQLC, PLACEHOLDER_DIALECT_TEMPLATE:
+						conf.Placeholder.Dialects = append(conf.Placeholder.Dialects, dialect)
+					default:
+						return nil, fmt.Errorf("unknown placeholder.dialects: %s", d)
+					}
+				}
+			}
+
+			if n := ymlconf.FormatSettings.Statement.BlankLines; n != nil && *n >= 0 {
+				conf.Statement.BlankLines = *n
+			}
+
+			switch semicolon := StatementSemicolonType(normalizeYamlValue(string(ymlconf.FormatSettings.Statement.Semicolon))); semicolon {
+			case "":
+			case STATEMENT_SEMICOLON_AS_NEEDED, STATEMENT_SEMICOLON_ALWAYS:
+				conf.Statement.Semicolon = semicolon
+			default:
+				return nil, fmt.Errorf("unknown statement.semicolon: %s", ymlconf.FormatSettings.Statement.Semicolon)
+			}
+
+			conf.Target.Include = append(conf.Target.Include, ymlconf.TargetSettings.Include...)
+			conf.Target.Exclude = append(conf.Target.Exclude, ymlconf.TargetSettings.Exclude...)
+
+			if len(ymlconf.TargetSettings.Sites) > 0 {
+				conf.Target.Sites = nil
+				for _, s := range ymlconf.TargetSettings.Sites {
+					switch site := TargetSite(normalizeYamlValue(string(s))); site {
+					case TARGET_SITE_CONST, TARGET_SITE_VAR, TARGET_SITE_ASSIGN, TARGET_SITE_STRUCT_FIELD, TARGET_SITE_FUNC_ARG:
+						conf.Target.Sites = append(conf.Target.Sites, site)
+					default:
+						return nil, fmt.Errorf("unknown sites: %s", s)
+					}
+				}
+			}
+			switch detectionType := DetectionType(normalizeYamlValue(string(ymlconf.TargetSettings.DetectionType))); detectionType {
+			case "":
+			case DETECTION_TYPE_PREFIX, DETECTION_TYPE_TYPES:
+				conf.Target.DetectionType = detectionType
+			default:
+				return nil, fmt.Errorf("unknown detection-type: %s", ymlconf.TargetSettings.DetectionType)
+			}
+			conf.Target.QueryFuncs = append(conf.Target.QueryFuncs, ymlconf.TargetSettings.QueryFuncs...)
+			conf.Target.QueryMethods = append(conf.Target.QueryMethods, ymlconf.TargetSettings.QueryMethods...)
 		}
 	}
 
 	return conf, nil
 }
+
+// normalizeYamlValue converts the value to the form of the constants, ex) struct-field to STRUCT_FIELD
+func normalizeYamlValue(v string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), "-", "_"))
+}
@@ -0,0 +1,87 @@
+package fmtconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadYamlConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    func(*Config)
+		wantErr bool
+	}{
+		{
+			name: "semicolon case insensitive",
+			yaml: "format-settings:\n  statement:\n    semicolon: always\n",
+			want: func(c *Config) { c.Statement.Semicolon = STATEMENT_SEMICOLON_ALWAYS },
+		},
+		{name: "unknown semicolon", yaml: "format-settings:\n  statement:\n    semicolon: never\n", wantErr: true},
+		{
+			name: "sites case insensitive",
+			yaml: "target-settings:\n  sites: [const, struct-field]\n",
+			want: func(c *Config) { c.Target.Sites = []TargetSite{TARGET_SITE_CONST, TARGET_SITE_STRUCT_FIELD} },
+		},
+		{name: "unknown sites", yaml: "target-settings:\n  sites: [const, return]\n", wantErr: true},
+		{
+			name: "detection-type case insensitive",
+			yaml: "target-settings:\n  detection-type: types\n",
+			want: func(c *Config) { c.Target.DetectionType = DETECTION_TYPE_TYPES },
+		},
+		{name: "unknown detection-type", yaml: "target-settings:\n  detection-type: suffix\n", wantErr: true},
+		{
+			name: "placeholder dialects case insensitive",
+			yaml: "format-settings:\n  placeholder:\n    dialects: [colon, Question]\n",
+			want: func(c *Config) {
+				c.Placeholder.Dialects = []PlaceholderDialect{PLACEHOLDER_DIALECT_COLON, PLACEHOLDER_DIALECT_QUESTION}
+			},
+		},
+		{name: "unknown placeholder dialects", yaml: "format-settings:\n  placeholder:\n    dialects: [colons]\n", wantErr: true},
+		{
+			name: "keyword-case case insensitive",
+			yaml: "format-settings:\n  keyword-case: Preserve\n",
+			want: func(c *Config) { c.KeywordCase = KEYWORD_CASE_PRESERVE },
+		},
+		{name: "unknown keyword-case", yaml: "format-settings:\n  keyword-case: camel\n", wantErr: true},
+		{
+			name: "identifier settings case insensitive",
+			yaml: "format-settings:\n  identifier-case: preserve\n  quote-identifiers: always\n",
+			want: func(c *Config) {
+				c.Identifier.Case = IDENTIFIER_CASE_PRESERVE
+				c.Identifier.Quote = QUOTE_IDENTIFIERS_ALWAYS
+			},
+		},
+		{name: "unknown identifier-case", yaml: "format-settings:\n  identifier-case: upper\n", wantErr: true},
+		{name: "unknown quote-identifiers", yaml: "format-settings:\n  quote-identifiers: never\n", wantErr: true},
+		{
+			name: "type-cast-style case insensitive",
+			yaml: "format-settings:\n  type-cast-style: cast\n",
+			want: func(c *Config) { c.TypeCastStyle = TYPE_CAST_STYLE_CAST },
+		},
+		{name: "unknown type-cast-style", yaml: "format-settings:\n  type-cast-style: convert\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, ".gopsqlfmt.yaml"), []byte(tt.yaml), 0o644))
+			wd, err := os.Getwd()
+			assert.NoError(t, err)
+			assert.NoError(t, os.Chdir(dir))
+			t.Cleanup(func() { assert.NoError(t, os.Chdir(wd)) })
+
+			got, err := LoadYamlConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			want := NewDefaultConfig()
+			tt.want(want)
+			assert.Equal(t, want, got)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// convertCase converts the case of the keywords and the identifiers of formatted.
+// The formatter outputs keywords in upper case and identifiers in lower case, but the deparser outputs some keywords in lower case,
+// ex) current_user, collation for (x), so the keywords are converted in both directions.
+// Function names follow FuncNameTypeCase and type names are left as they are output.
+func convertCase(input, formatted string, conf *fmtconf.Config) (string, error) {
+	preserveKeyword := conf.KeywordCase == fmtconf.KEYWORD_CASE_PRESERVE
+	preserveIdentifier := conf.Identifier.Case == fmtconf.IDENTIFIER_CASE_PRESERVE
+
+	outTokens, _, err := scanSQL(formatted)
+	if err != nil {
+		return "", err
+	}
+	starts, err := nameStarts(formatted)
+	if err != nil {
+		// the formatted sql is verified later
+		return formatted, nil
+	}
+
+	// the token of the input that each output token comes from
+	var inTokens []sqlToken
+	source := make([]int, len(outTokens))
+	for i := range source {
+		source[i] = -1
+	}
+	if preserveKeyword || preserveIdentifier {
+		if inTokens, _, err = scanSQL(input); err != nil {
+			return "", err
+		}
+		for i, j := range matchTokens(inTokens, outTokens) {
+			if j >= 0 {
+				source[j] = i
+			}
+		}
+	}
+
+	replacements := map[int]string{}
+	// the keywords added by the formatter follow the case of most keywords of the input, ex) INNER of join
+	var added []int
+	lowerKeywords, upperKeywords := 0, 0
+	nameLast := -1
+	for i, t := range outTokens {
+		text := formatted[t.start:t.end]
+		// skip the function name until the opening parenthesis, ex) pg_catalog.LEFT(
+		// the functions called by the syntax start at a keyword, ex) name LIKE $1 ESCAPE '!'
+		if starts.funcs[t.start] {
+			nameLast = funcNameEnd(formatted, outTokens, i)
+		}
+		// skip the type name, ex) timestamp(3) with time zone
+		if starts.types[t.start] {
+			nameLast = typeNameEnd(formatted, outTokens, i)
+		}
+		if i <= nameLast {
+			continue
+		}
+		// the constants are output as written unless keywords are converted to lower case, ex) true, NULL
+		if starts.lowerOnly[t.start] && conf.KeywordCase == fmtconf.KEYWORD_CASE_UPPER {
+			continue
+		}
+
+		// the keywords that can be identifiers are keywords only in upper case, identifiers are quoted if they are other keywords.
+		// EXCLUDED of ON CONFLICT DO UPDATE is output like a keyword
+		keyword := (t.keyword && (!t.identifier || text == strings.ToUpper(text))) ||
+			(text == "EXCLUDED" && i+1 < len(outTokens) && formatted[outTokens[i+1].start:outTokens[i+1].end] == ".")
+
+		var replaced string
+		switch {
+		case keyword && preserveKeyword:
+			if source[i] < 0 {
+				added = append(added, i)
+				continue
+			}
+			replaced = input[inTokens[source[i]].start:inTokens[source[i]].end]
+			switch replaced {
+			case strings.ToLower(replaced):
+				lowerKeywords++
+			case strings.ToUpper(replaced):
+				upperKeywords++
+			}
+		case keyword && conf.KeywordCase == fmtconf.KEYWORD_CASE_LOWER:
+			replaced = strings.ToLower(text)
+		case keyword:
+			replaced = strings.ToUpper(text)
+		case t.identifier && text == strings.ToLower(text) && preserveIdentifier:
+			// quoted identifiers of the input are case sensitive, ex) "UserName"
+			if source[i] < 0 || !inTokens[source[i]].identifier {
+				continue
+			}
+			replaced = input[inTokens[source[i]].start:inTokens[source[i]].end]
+		default:
+			continue
+		}
+		replacements[i] = replaced
+	}
+	for _, i := range added {
+		text := formatted[outTokens[i].start:outTokens[i].end]
+		if lowerKeywords > upperKeywords {
+			replacements[i] = strings.ToLower(text)
+		} else {
+			replacements[i] = strings.ToUpper(text)
+		}
+	}
+
+	var bu strings.Builder
+	last := 0
+	for i, t := range outTokens {
+		replaced, ok := replacements[i]
+		if !ok || replaced == formatted[t.start:t.end] {
+			continue
+		}
+		bu.WriteString(formatted[last:t.start])
+		bu.WriteString(replaced)
+		last = t.end
+	}
+	bu.WriteString(formatted[last:])
+
+	return bu.String(), nil
+}
+
+// funcNameEnd returns the index of the opening parenthesis after the function name that starts at i, or -1
+// ex) pg_catalog.left(
+func funcNameEnd(sql string, tokens []sqlToken, i int) int {
+	for k := i; k < len(tokens); k++ {
+		text := sql[tokens[k].start:tokens[k].end]
+		// names and dots alternate
+		if (k-i)%2 == 1 {
+			if text == "(" {
+				return k
+			}
+			if text != "." {
+				return -1
+			}
+		} else if !tokens[k].keyword && !tokens[k].identifier && !strings.HasPrefix(text, `"`) {
+			return -1
+		}
+	}
+	return -1
+}
+
+// typeNameEnd returns the index of the last token of the type name that starts at i,
+// the words of the type name are in lower case, ex) pg_catalog.int4, varchar(10)[], timestamp(3) with time zone
+func typeNameEnd(sql string, tokens []sqlToken, i int) int {
+	last := i
+	depth := 0
+	for k := i + 1; k < len(tokens); k++ {
+		text := sql[tokens[k].start:tokens[k].end]
+		switch {
+		case text == "(" || text == "[":
+			depth++
+		case text == ")" || text == "]":
+			if depth == 0 {
+				return last
+			}
+			depth--
+		case depth > 0, text == ".":
+			// the type modifiers and the array bounds
+		case (tokens[k].keyword || tokens[k].identifier) && text == strings.ToLower(text):
+		default:
+			return last
+		}
+		last = k
+	}
+	return last
+}
+
+// treeStarts are the positions of the nodes of the formatted sql whose case is not converted
+type treeStarts struct {
+	funcs map[int]bool
+	types map[int]bool
+	// lowerOnly are the keyword constants and FROM user, they are not converted to upper case
+	lowerOnly map[int]bool
+}
+
+// nameStarts returns the positions of the names of the function calls, the type names and the keyword constants in sql
+func nameStarts(sql string) (treeStarts, error) {
+	tree, err := pg_query.Parse(sql)
+	if err != nil {
+		return treeStarts{}, err
+	}
+
+	starts := treeStarts{funcs: map[int]bool{}, types: map[int]bool{}, lowerOnly: map[int]bool{}}
+	var walk func(msg protoreflect.Message)
+	walk = func(msg protoreflect.Message) {
+		switch n := msg.Interface().(type) {
+		case *pg_query.FuncCall:
+			// TRIM(BOTH FROM name) and the like are keywords
+			if n.Funcformat == pg_query.CoercionForm_COERCE_EXPLICIT_CALL {
+				starts.funcs[int(n.Location)] = true
+			}
+		case *pg_query.TypeName:
+			if n.Location >= 0 {
+				starts.types[int(n.Location)] = true
+			}
+		case *pg_query.A_Const:
+			if n.Location >= 0 && (n.Isnull || n.GetBoolval() != nil) {
+				starts.lowerOnly[int(n.Location)] = true
+			}
+		case *pg_query.RangeFunction:
+			// ex) FROM user
+			if isUserRangeFunction(&pg_query.Node_RangeFunction{RangeFunction: n}) {
+				starts.lowerOnly[int(n.Functions[0].GetList().Items[0].GetSqlvalueFunction().Location)] = true
+			}
+		}
+		msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			if fd.Message() == nil || fd.IsMap() {
+				return true
+			}
+			if fd.IsList() {
+				for i := 0; i < v.List().Len(); i++ {
+					walk(v.List().Get(i).Message())
+				}
+				return true
+			}
+			walk(v.Message())
+			return true
+		})
+	}
+	walk(tree.ProtoReflect())
+
+	return starts, nil
+}
@@ -0,0 +1,300 @@
+package formatter
+
+import (
+	"sort"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// sqlComment is a comment of the input sql
+type sqlComment struct {
+	text string
+	// trailing is true when the comment follows a token on the same line, ex) user_name, -- name of user
+	trailing bool
+	// inline is true for a block comment followed by a token on the same line, it stays next to the tokens
+	// ex) AND /* mid */ y = 2, SELECT /*+ SeqScan(users) */
+	inline bool
+	// anchor is the index of the token the comment belongs to, leading comments after the last token have len(tokens)
+	anchor int
+}
+
+type sqlToken struct {
+	start, end int
+	key        string
+	keyword    bool
+	// identifier is an identifier without quotes or an unreserved keyword, which can be an identifier
+	identifier bool
+}
+
+// scanSQL splits sql into the tokens and the comments, pg_query.Parse discards the comments
+func scanSQL(sql string) ([]sqlToken, []sqlComment, error) {
+	res, err := pg_query.Scan(sql)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tokens []sqlToken
+	var comments []sqlComment
+	// the end of each block comment, -1 for line comments
+	var blockEnds []int
+	for _, t := range res.Tokens {
+		start, end := int(t.Start), int(t.End)
+		if t.Token != pg_query.Token_SQL_COMMENT && t.Token != pg_query.Token_C_COMMENT {
+			tokens = append(tokens, sqlToken{
+				start:      start,
+				end:        end,
+				key:        tokenKey(sql, t),
+				keyword:    t.KeywordKind != pg_query.KeywordKind_NO_KEYWORD,
+				identifier: (t.Token == pg_query.Token_IDENT && sql[start] != '"') || t.KeywordKind == pg_query.KeywordKind_UNRESERVED_KEYWORD,
+			})
+			continue
+		}
+
+		c := sqlComment{text: strings.TrimRight(sql[start:end], " \t\r\n"), anchor: len(tokens)}
+		if len(tokens) > 0 && !strings.Contains(sql[tokens[len(tokens)-1].end:start], "\n") {
+			c.trailing = true
+			c.anchor = len(tokens) - 1
+		}
+		if t.Token == pg_query.Token_C_COMMENT {
+			blockEnds = append(blockEnds, end)
+		} else {
+			blockEnds = append(blockEnds, -1)
+		}
+		comments = append(comments, c)
+	}
+
+	for ci := range comments {
+		next := comments[ci].anchor
+		if comments[ci].trailing {
+			next++
+		}
+		comments[ci].inline = blockEnds[ci] >= 0 && next < len(tokens) && !strings.Contains(sql[blockEnds[ci]:tokens[next].start], "\n")
+	}
+
+	return tokens, comments, nil
+}
+
+// tokenKey is used to match the tokens of the input and the formatted sql, keywords are compared regardless of case
+func tokenKey(sql string, t *pg_query.ScanToken) string {
+	if t.KeywordKind != pg_query.KeywordKind_NO_KEYWORD {
+		return t.Token.String()
+	}
+	return t.Token.String() + ":" + strings.ToLower(sql[t.Start:t.End])
+}
+
+// matchTokens maps the index of an input token to the index of the same output token, -1 if it has been removed
+func matchTokens(in, out []sqlToken) []int {
+	match := make([]int, len(in))
+	for i := range match {
+		match[i] = -1
+	}
+	inIndexes := make([]int, len(in))
+	for i := range inIndexes {
+		inIndexes[i] = i
+	}
+	outIndexes := make([]int, len(out))
+	for j := range outIndexes {
+		outIndexes[j] = j
+	}
+	matchSubsequence(in, out, inIndexes, outIndexes, match)
+
+	// the words moved by the formatter are matched among the rest, ex) OFFSET 5 LIMIT 10 is output as LIMIT 10 OFFSET 5
+	matched := make([]bool, len(out))
+	var restIn, restOut []int
+	for i, j := range match {
+		if j >= 0 {
+			matched[j] = true
+		} else if !isPunctuation(in[i]) {
+			restIn = append(restIn, i)
+		}
+	}
+	for j := range out {
+		if !matched[j] && !isPunctuation(out[j]) {
+			restOut = append(restOut, j)
+		}
+	}
+	matchSubsequence(in, out, restIn, restOut, match)
+
+	return match
+}
+
+// matchSubsequence matches the longest common subsequence of the input tokens at inIndexes and the output tokens at outIndexes
+func matchSubsequence(in, out []sqlToken, inIndexes, outIndexes []int, match []int) {
+	lcs := make([][]int, len(inIndexes)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(outIndexes)+1)
+	}
+	for i := len(inIndexes) - 1; i >= 0; i-- {
+		for j := len(outIndexes) - 1; j >= 0; j-- {
+			if in[inIndexes[i]].key == out[outIndexes[j]].key {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < len(inIndexes) && j < len(outIndexes); {
+		switch {
+		case in[inIndexes[i]].key == out[outIndexes[j]].key:
+			match[inIndexes[i]] = outIndexes[j]
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+}
+
+// isPunctuation reports whether the token is a single character such as ( and ,
+func isPunctuation(t sqlToken) bool {
+	return strings.HasPrefix(t.key, "ASCII_")
+}
+
+// attachComments outputs the comments of input to the line of formatted that contains the token they belong to
+func attachComments(input, formatted string) (string, error) {
+	inTokens, comments, err := scanSQL(input)
+	if err != nil {
+		return "", err
+	}
+	if len(comments) == 0 {
+		return formatted, nil
+	}
+	outTokens, _, err := scanSQL(formatted)
+	if err != nil {
+		return "", err
+	}
+	match := matchTokens(inTokens, outTokens)
+
+	type insertion struct {
+		pos  int
+		text string
+		// order keeps the order of the comments that are output at the same position
+		order int
+	}
+	var insertions []insertion
+	var trailingLine = map[int]string{}
+
+	for ci, c := range comments {
+		// the token of a trailing comment has been removed, the comment goes to the clause that follows it
+		if c.trailing && match[c.anchor] < 0 && hasMatchAfter(match, c.anchor) {
+			c.trailing = false
+			c.anchor++
+		}
+
+		// find the nearest token that remains in the formatted sql
+		anchor := -1
+		if c.trailing {
+			for i := c.anchor; i >= 0; i-- {
+				if match[i] >= 0 {
+					anchor = match[i]
+					break
+				}
+			}
+		} else {
+			for i := c.anchor; i < len(inTokens); i++ {
+				if match[i] >= 0 {
+					anchor = match[i]
+					break
+				}
+			}
+		}
+
+		switch {
+		case anchor < 0:
+			// no token follows the comment, output the comment at the end
+			insertions = append(insertions, insertion{pos: len(formatted), text: c.text + "\n", order: ci})
+		case c.inline && c.trailing:
+			insertions = append(insertions, insertion{pos: outTokens[anchor].end, text: " " + c.text, order: ci})
+		case c.inline:
+			insertions = append(insertions, insertion{pos: outTokens[anchor].start, text: c.text + " ", order: ci})
+		case c.trailing:
+			pos := lineEnd(formatted, outTokens, outTokens[anchor].end)
+			text := " " + c.text
+			// a line comment hides everything after it, so the next comment goes to a new line
+			if prev, ok := trailingLine[pos]; ok && strings.HasPrefix(prev, "--") {
+				text = "\n" + lineIndent(formatted, outTokens, outTokens[anchor].start) + c.text
+			}
+			trailingLine[pos] = c.text
+			insertions = append(insertions, insertion{pos: pos, text: text, order: ci})
+		default:
+			pos := lineStart(formatted, outTokens, outTokens[anchor].start)
+			text := lineIndent(formatted, outTokens, outTokens[anchor].start) + c.text + "\n"
+			insertions = append(insertions, insertion{pos: pos, text: text, order: ci})
+		}
+	}
+
+	sort.SliceStable(insertions, func(i, j int) bool {
+		if insertions[i].pos != insertions[j].pos {
+			return insertions[i].pos < insertions[j].pos
+		}
+		return insertions[i].order < insertions[j].order
+	})
+
+	var bu strings.Builder
+	last := 0
+	for _, ins := range insertions {
+		bu.WriteString(formatted[last:ins.pos])
+		bu.WriteString(ins.text)
+		last = ins.pos
+	}
+	bu.WriteString(formatted[last:])
+	return bu.String(), nil
+}
+
+// hasMatchAfter reports whether a token after i remains in the formatted sql
+func hasMatchAfter(match []int, i int) bool {
+	for _, j := range match[i+1:] {
+		if j >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lineStart returns the start of the line that contains pos, lines inside a multi-line token are skipped
+func lineStart(sql string, tokens []sqlToken, pos int) int {
+	for {
+		start := strings.LastIndex(sql[:pos], "\n") + 1
+		t, ok := tokenAt(tokens, start)
+		if !ok {
+			return start
+		}
+		pos = t.start
+	}
+}
+
+// lineEnd returns the end of the line that contains pos, lines inside a multi-line token are skipped
+func lineEnd(sql string, tokens []sqlToken, pos int) int {
+	for {
+		end := strings.Index(sql[pos:], "\n")
+		if end < 0 {
+			return len(sql)
+		}
+		end += pos
+		t, ok := tokenAt(tokens, end)
+		if !ok {
+			return end
+		}
+		pos = t.end
+	}
+}
+
+func lineIndent(sql string, tokens []sqlToken, pos int) string {
+	start := lineStart(sql, tokens, pos)
+	line := sql[start:]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// tokenAt returns the token that contains pos
+func tokenAt(tokens []sqlToken, pos int) (sqlToken, bool) {
+	for _, t := range tokens {
+		if t.start < pos && pos < t.end {
+			return t, true
+		}
+	}
+	return sqlToken{}, false
+}
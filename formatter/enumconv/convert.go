@@ -67,3 +67,21 @@ func NullTestTypeToString(ntt pg_query.NullTestType) (string, error) {
 	}
 	return "", errors.New("NullTestTypeToString: unknown NullTestType")
 }
+
+func BoolTestTypeToString(btt pg_query.BoolTestType) (string, error) {
+	switch btt {
+	case pg_query.BoolTestType_IS_TRUE:
+		return "IS TRUE", nil
+	case pg_query.BoolTestType_IS_NOT_TRUE:
+		return "IS NOT TRUE", nil
+	case pg_query.BoolTestType_IS_FALSE:
+		return "IS FALSE", nil
+	case pg_query.BoolTestType_IS_NOT_FALSE:
+		return "IS NOT FALSE", nil
+	case pg_query.BoolTestType_IS_UNKNOWN:
+		return "IS UNKNOWN", nil
+	case pg_query.BoolTestType_IS_NOT_UNKNOWN:
+		return "IS NOT UNKNOWN", nil
+	}
+	return "", errors.New("BoolTestTypeToString: unknown BoolTestType")
+}
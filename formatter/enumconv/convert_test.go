@@ -52,3 +52,47 @@ func TestBoolExprTypeToString(t *testing.T) {
 		})
 	}
 }
+
+func TestBoolTestTypeToString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		testType pg_query.BoolTestType
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "IS TRUE",
+			testType: pg_query.BoolTestType_IS_TRUE,
+			want:     "IS TRUE",
+		},
+		{
+			name:     "IS NOT FALSE",
+			testType: pg_query.BoolTestType_IS_NOT_FALSE,
+			want:     "IS NOT FALSE",
+		},
+		{
+			name:     "IS UNKNOWN",
+			testType: pg_query.BoolTestType_IS_UNKNOWN,
+			want:     "IS UNKNOWN",
+		},
+		{
+			name:     "unknown",
+			testType: pg_query.BoolTestType(999),
+			wantErr:  errors.New("BoolTestTypeToString: unknown BoolTestType"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := enumconv.BoolTestTypeToString(tt.testType)
+			assert.Equal(t, tt.want, actual)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package formatter
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
@@ -13,12 +12,10 @@ import (
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-const (
-	castParamPrefix  = "::"
-	namedParamPrefix = ":"
-	cpMarkPrefix     = "castpre_"
-	npMarkPrefix     = "ttpre_"
-)
+func init() {
+	// subqueries in expressions are formatted in the same way as SELECT statements
+	nodeformatter.FormatSubquery = FormatSelectStmt
+}
 
 func Format(sql string, conf *fmtconf.Config) (string, error) {
 	ctx := context.Background()
@@ -26,366 +23,449 @@ func Format(sql string, conf *fmtconf.Config) (string, error) {
 		conf = fmtconf.NewDefaultConfig()
 	}
 
-	// support named parameter
-	replacedSQL := strings.NewReplacer([]string{
-		castParamPrefix, cpMarkPrefix,
-		namedParamPrefix, npMarkPrefix,
-	}...).Replace(sql)
-	replacedSQL = strings.NewReplacer([]string{
-		cpMarkPrefix, castParamPrefix,
-	}...).Replace(replacedSQL)
+	// support placeholders such as named parameters
+	var ph placeholders
+	replacedSQL := ph.protect(sql, conf)
 
 	result, err := pg_query.Parse(replacedSQL)
+	if err != nil {
+		return "", ph.parseError(replacedSQL, err)
+	}
+	// literals are output as written in the source
+	ctx, err = internal.WithSource(ctx, replacedSQL)
+	if err != nil {
+		return "", err
+	}
+	sources, err := splitStatementSources(replacedSQL, result.Stmts)
 	if err != nil {
 		return "", err
 	}
+
 	var strBuilder strings.Builder
 	strBuilder.WriteString("\n")
-	for _, raw := range result.Stmts {
-		switch stmt := raw.Stmt.Node.(type) {
-		case *pg_query.Node_SelectStmt:
-			res, err := FormatSelectStmt(ctx, stmt, 0, conf)
-			if err != nil {
-				return "", err
-			}
-			strBuilder.WriteString(res)
+	for i, raw := range result.Stmts {
+		res, err := formatStmt(ctx, raw, conf)
+		if err != nil {
+			return "", err
+		}
+		res, err = convertCase(sources[i].text, res, conf)
+		if err != nil {
+			return "", err
+		}
 
-		case *pg_query.Node_InsertStmt:
-			strBuilder.WriteString("INSERT INTO")
+		// output the comments discarded by the parser
+		res, err = attachComments(sources[i].text, res+"\n")
+		if err != nil {
+			return "", err
+		}
+		res = strings.TrimSuffix(res, "\n")
 
-			// output table name
-			tableName, err := nodeformatter.FormatRelation(ctx, stmt.InsertStmt.Relation)
+		if len(result.Stmts) > 1 || sources[i].semicolon || conf.Statement.Semicolon == fmtconf.STATEMENT_SEMICOLON_ALWAYS {
+			res, err = addSemicolon(res)
 			if err != nil {
 				return "", err
 			}
-			strBuilder.WriteString(tableName)
+		}
 
-			if len(stmt.InsertStmt.Cols) > 0 {
-				strBuilder.WriteString("(")
+		if i != 0 {
+			strBuilder.WriteString(strings.Repeat("\n", conf.Statement.BlankLines))
+		}
+		strBuilder.WriteString(res)
+		strBuilder.WriteString("\n")
+	}
+	formatted := strBuilder.String()
+	if len(result.Stmts) == 0 {
+		if formatted, err = attachComments(replacedSQL, formatted); err != nil {
+			return "", err
+		}
+	}
 
-				// output column name
-				for i, col := range stmt.InsertStmt.Cols {
-					if target, ok := col.Node.(*pg_query.Node_ResTarget); ok {
-						if i != 0 {
-							strBuilder.WriteString(",")
-						}
-						strBuilder.WriteString("\n")
-						strBuilder.WriteString(internal.GetIndent(conf))
-						strBuilder.WriteString(target.ResTarget.Name)
-					}
-				}
+	// verify that the formatted sql has the same meaning as the input sql
+	if err := verifyLossless(result, formatted); err != nil {
+		if lerr, ok := err.(*LosslessError); ok {
+			lerr.Formatted = ph.restore(lerr.Formatted)
+		}
+		return "", err
+	}
 
-				strBuilder.WriteString("\n")
-				strBuilder.WriteString(") ")
-			} else {
-				strBuilder.WriteString("\n")
-			}
+	return ph.restore(formatted), nil
+}
 
-			// output parameter
-			if stmt.InsertStmt.SelectStmt != nil {
-				if sNode, ok := stmt.InsertStmt.SelectStmt.Node.(*pg_query.Node_SelectStmt); ok {
-					for _, value := range sNode.SelectStmt.ValuesLists {
-						strBuilder.WriteString("VALUES (")
-						if list, ok := value.Node.(*pg_query.Node_List); ok {
-							for itemI, item := range list.List.Items {
-								if itemI != 0 {
-									strBuilder.WriteString(",")
-								}
-								switch v := item.Node.(type) {
-								case *pg_query.Node_ParamRef:
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									strBuilder.WriteString("$")
-									strBuilder.WriteString(fmt.Sprint(v.ParamRef.Number))
-								case *pg_query.Node_ColumnRef:
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									field, err := nodeformatter.FormatColumnRefFields(ctx, v)
-									if err != nil {
-										return "", err
-									}
-									strBuilder.WriteString(field)
-								case *pg_query.Node_AConst:
-									aconst, err := nodeformatter.FormatAConst(ctx, v)
-									if err != nil {
-										return "", err
-									}
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									strBuilder.WriteString(aconst)
-								case *pg_query.Node_FuncCall:
-									funcName, err := nodeformatter.FormatFuncname(ctx, v, conf)
-									if err != nil {
-										return "", err
-									}
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									strBuilder.WriteString(funcName)
-									strBuilder.WriteString("(")
-
-									arg, err := nodeformatter.FormatFuncCallArgs(ctx, v, 0, conf)
-									if err != nil {
-										return "", err
-									}
-									strBuilder.WriteString(arg)
-
-									strBuilder.WriteString(")")
-								case *pg_query.Node_SqlvalueFunction:
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									switch v.SqlvalueFunction.Op {
-									case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIMESTAMP:
-										strBuilder.WriteString("CURRENT_TIMESTAMP")
-									case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_DATE:
-										strBuilder.WriteString("CURRENT_DATE")
-									case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIME:
-										strBuilder.WriteString("CURRENT_TIME")
-									case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIME:
-										strBuilder.WriteString("LOCALTIME")
-									case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIMESTAMP:
-										strBuilder.WriteString("LOCALTIMESTAMP")
-									default:
-										strBuilder.WriteString(fmt.Sprintf("/* UNHANDLED SqlvalueFunction: %v */", v.SqlvalueFunction.Op))
-									}
-								default:
-									// Handle unknown node types to prevent SQL content loss
-									strBuilder.WriteString("\n")
-									strBuilder.WriteString(internal.GetIndent(conf))
-									// For debugging: add a placeholder that shows the unhandled type
-									strBuilder.WriteString(fmt.Sprintf("/* UNHANDLED: %T */", v))
-								}
-							}
-						}
-						strBuilder.WriteString("\n")
-						strBuilder.WriteString(")")
-					}
+func formatStmt(ctx context.Context, raw *pg_query.RawStmt, conf *fmtconf.Config) (string, error) {
+	switch stmt := raw.Stmt.Node.(type) {
+	case *pg_query.Node_SelectStmt:
+		return FormatSelectStmt(ctx, stmt, 0, conf)
+	case *pg_query.Node_InsertStmt:
+		return formatInsertStmt(ctx, stmt, conf)
+	case *pg_query.Node_UpdateStmt:
+		return formatUpdateStmt(ctx, stmt, conf)
+	case *pg_query.Node_DeleteStmt:
+		return formatDeleteStmt(ctx, stmt, conf)
+	}
+	return nodeformatter.DeparseStmt(ctx, raw.Stmt)
+}
 
-					res, err := FormatSelectStmt(ctx, sNode, 0, conf)
-					if err != nil {
-						return "", err
-					}
-					strBuilder.WriteString(res)
-				}
-			}
+func formatInsertStmt(ctx context.Context, stmt *pg_query.Node_InsertStmt, conf *fmtconf.Config) (string, error) {
+	// output the whole statement by the deparser if it has clauses without dedicated formatter
+	// ex) INSERT INTO users DEFAULT VALUES, INSERT INTO users OVERRIDING SYSTEM VALUE VALUES (...)
+	if stmt.InsertStmt.SelectStmt == nil || stmt.InsertStmt.Override != pg_query.OverridingKind_OVERRIDING_NOT_SET {
+		return nodeformatter.DeparseStmt(ctx, &pg_query.Node{Node: stmt})
+	}
 
-			// output on conflict
-			if stmt.InsertStmt.OnConflictClause != nil {
-				strBuilder.WriteString("\n")
-				strBuilder.WriteString("ON CONFLICT")
-				if stmt.InsertStmt.OnConflictClause.Infer != nil {
-					if len(stmt.InsertStmt.OnConflictClause.Infer.IndexElems) > 0 {
-						strBuilder.WriteString("(")
-					}
-					for i, elm := range stmt.InsertStmt.OnConflictClause.Infer.IndexElems {
-						if idxElm, ok := elm.Node.(*pg_query.Node_IndexElem); ok {
-							if i > 0 {
-								strBuilder.WriteString(", ")
-							}
-							strBuilder.WriteString(idxElm.IndexElem.Name)
-						}
-					}
-					if len(stmt.InsertStmt.OnConflictClause.Infer.IndexElems) > 0 {
-						strBuilder.WriteString(")")
-					}
+	var strBuilder strings.Builder
 
-					if stmt.InsertStmt.OnConflictClause.Infer.Conname != "" {
-						strBuilder.WriteString(" ")
-						strBuilder.WriteString("ON CONSTRAINT")
-						strBuilder.WriteString(" ")
-						strBuilder.WriteString(stmt.InsertStmt.OnConflictClause.Infer.Conname)
-					}
+	// output with clause
+	if stmt.InsertStmt.WithClause != nil {
+		res, err := formatWithClause(ctx, stmt.InsertStmt.WithClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+		strBuilder.WriteString("\n")
+	}
+
+	strBuilder.WriteString("INSERT INTO")
+
+	// output table name, the alias of INSERT needs AS
+	strBuilder.WriteString(" ")
+	strBuilder.WriteString(nodeformatter.FormatRangeVarName(stmt.InsertStmt.Relation, conf))
+	if stmt.InsertStmt.Relation.Alias != nil {
+		strBuilder.WriteString(" AS ")
+		strBuilder.WriteString(nodeformatter.FormatAlias(stmt.InsertStmt.Relation.Alias, conf))
+	}
+
+	if len(stmt.InsertStmt.Cols) > 0 {
+		strBuilder.WriteString("(")
+
+		// output column name
+		for i, col := range stmt.InsertStmt.Cols {
+			if target, ok := col.Node.(*pg_query.Node_ResTarget); ok {
+				if i != 0 {
+					strBuilder.WriteString(",")
 				}
+				strBuilder.WriteString("\n")
+				strBuilder.WriteString(internal.GetIndent(conf))
+				strBuilder.WriteString(nodeformatter.FormatIdentifier(target.ResTarget.Name, conf))
+			}
+		}
+
+		strBuilder.WriteString("\n")
+		strBuilder.WriteString(") ")
+	} else {
+		strBuilder.WriteString("\n")
+	}
 
-				switch stmt.InsertStmt.OnConflictClause.Action {
-				case pg_query.OnConflictAction_ONCONFLICT_NOTHING:
-					strBuilder.WriteString("\n")
-					strBuilder.WriteString("DO NOTHING")
-				case pg_query.OnConflictAction_ONCONFLICT_UPDATE:
-					strBuilder.WriteString("\n")
-					strBuilder.WriteString("DO UPDATE SET")
+	// output parameter
+	if stmt.InsertStmt.SelectStmt != nil {
+		if sNode, ok := stmt.InsertStmt.SelectStmt.Node.(*pg_query.Node_SelectStmt); ok {
+			switch len(sNode.SelectStmt.ValuesLists) {
+			case 0:
+				res, err := FormatSelectStmt(ctx, sNode, 0, conf)
+				if err != nil {
+					return "", err
 				}
-				for targetI, target := range stmt.InsertStmt.OnConflictClause.TargetList {
-					if res, ok := target.Node.(*pg_query.Node_ResTarget); ok {
-						if targetI != 0 {
+				strBuilder.WriteString(res)
+			case 1:
+				strBuilder.WriteString("VALUES (")
+				if list, ok := sNode.SelectStmt.ValuesLists[0].Node.(*pg_query.Node_List); ok {
+					for itemI, item := range list.List.Items {
+						if itemI != 0 {
 							strBuilder.WriteString(",")
 						}
+						res, err := nodeformatter.FormatExpr(ctx, item, 1, conf)
+						if err != nil {
+							return "", err
+						}
 						strBuilder.WriteString("\n")
 						strBuilder.WriteString(internal.GetIndent(conf))
-						strBuilder.WriteString(res.ResTarget.Name)
-						strBuilder.WriteString(" = ")
-
-						if res.ResTarget.Val != nil {
-							switch n := res.ResTarget.Val.Node.(type) {
-							case *pg_query.Node_ColumnRef:
-								field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-								if err != nil {
-									return "", err
-								}
-								strBuilder.WriteString(field)
-							case *pg_query.Node_ParamRef:
-								strBuilder.WriteString("$")
-								strBuilder.WriteString(fmt.Sprint(n.ParamRef.Number))
-							case *pg_query.Node_FuncCall:
-								res, err := nodeformatter.FormatFuncname(ctx, n, conf)
-								if err != nil {
-									return "", err
-								}
-								strBuilder.WriteString(res)
-								strBuilder.WriteString("(")
-								strBuilder.WriteString(")")
-							case *pg_query.Node_SqlvalueFunction:
-								switch n.SqlvalueFunction.Op {
-								case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIMESTAMP:
-									strBuilder.WriteString("CURRENT_TIMESTAMP")
-								case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_DATE:
-									strBuilder.WriteString("CURRENT_DATE")
-								case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIME:
-									strBuilder.WriteString("CURRENT_TIME")
-								case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIME:
-									strBuilder.WriteString("LOCALTIME")
-								case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIMESTAMP:
-									strBuilder.WriteString("LOCALTIMESTAMP")
-								}
-							case *pg_query.Node_AConst:
-								aconst, err := nodeformatter.FormatAConst(ctx, n)
-								if err != nil {
-									return "", err
-								}
-								strBuilder.WriteString(aconst)
-							}
-						}
+						strBuilder.WriteString(res)
 					}
 				}
+				strBuilder.WriteString("\n")
+				strBuilder.WriteString(")")
+			default:
+				// ex) VALUES (1, 'a'), (2, 'b')
+				res, err := nodeformatter.DeparseNode(ctx, stmt.InsertStmt.SelectStmt)
+				if err != nil {
+					return "", err
+				}
+				strBuilder.WriteString(res)
 			}
-		case *pg_query.Node_UpdateStmt:
-			strBuilder.WriteString("UPDATE")
+		}
+	}
 
-			// output table name
-			tableName, err := nodeformatter.FormatRelation(ctx, stmt.UpdateStmt.Relation)
+	// output on conflict
+	if stmt.InsertStmt.OnConflictClause != nil {
+		strBuilder.WriteString("\n")
+		strBuilder.WriteString("ON CONFLICT")
+		if infer := stmt.InsertStmt.OnConflictClause.Infer; infer != nil && !isBareColumnInfer(infer) {
+			// ex) ON CONFLICT(lower(email)) WHERE deleted_at IS NULL
+			res, err := nodeformatter.DeparseNode(ctx, &pg_query.Node{Node: &pg_query.Node_InferClause{InferClause: infer}})
 			if err != nil {
 				return "", err
 			}
-			strBuilder.WriteString(tableName)
-
-			strBuilder.WriteString("\n")
-			strBuilder.WriteString("SET")
-
-			for targetI, target := range stmt.UpdateStmt.TargetList {
-				if res, ok := target.Node.(*pg_query.Node_ResTarget); ok {
-					if targetI != 0 {
-						strBuilder.WriteString(",")
-					}
-					strBuilder.WriteString("\n")
-					strBuilder.WriteString(internal.GetIndent(conf))
-					strBuilder.WriteString(res.ResTarget.Name)
-					strBuilder.WriteString(" = ")
-
-					if res.ResTarget.Val != nil {
-						switch n := res.ResTarget.Val.Node.(type) {
-						case *pg_query.Node_ColumnRef:
-							field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-							if err != nil {
-								return "", err
-							}
-							strBuilder.WriteString(field)
-						case *pg_query.Node_ParamRef:
-							strBuilder.WriteString("$")
-							strBuilder.WriteString(fmt.Sprint(n.ParamRef.Number))
-						case *pg_query.Node_FuncCall:
-							res, err := nodeformatter.FormatFuncname(ctx, n, conf)
-							if err != nil {
-								return "", err
-							}
-							strBuilder.WriteString(res)
-							strBuilder.WriteString("(")
-							strBuilder.WriteString(")")
-						case *pg_query.Node_SqlvalueFunction:
-							switch n.SqlvalueFunction.Op {
-							case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIMESTAMP:
-								strBuilder.WriteString("CURRENT_TIMESTAMP")
-							case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_DATE:
-								strBuilder.WriteString("CURRENT_DATE")
-							case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIME:
-								strBuilder.WriteString("CURRENT_TIME")
-							case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIME:
-								strBuilder.WriteString("LOCALTIME")
-							case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIMESTAMP:
-								strBuilder.WriteString("LOCALTIMESTAMP")
-							}
-						}
+			strBuilder.WriteString(res)
+		} else if stmt.InsertStmt.OnConflictClause.Infer != nil {
+			if len(stmt.InsertStmt.OnConflictClause.Infer.IndexElems) > 0 {
+				strBuilder.WriteString("(")
+			}
+			for i, elm := range stmt.InsertStmt.OnConflictClause.Infer.IndexElems {
+				if idxElm, ok := elm.Node.(*pg_query.Node_IndexElem); ok {
+					if i > 0 {
+						strBuilder.WriteString(", ")
 					}
+					strBuilder.WriteString(nodeformatter.FormatIdentifier(idxElm.IndexElem.Name, conf))
 				}
 			}
+			if len(stmt.InsertStmt.OnConflictClause.Infer.IndexElems) > 0 {
+				strBuilder.WriteString(")")
+			}
 
-			// output where clause
-			if stmt.UpdateStmt.WhereClause != nil {
-				var (
-					res string
-					err error
-				)
-				if n, ok := stmt.UpdateStmt.WhereClause.Node.(*pg_query.Node_AExpr); ok {
-					res, err = nodeformatter.FormatAExpr(ctx, n, conf)
-				}
-				if nBoolExpr, ok := stmt.UpdateStmt.WhereClause.Node.(*pg_query.Node_BoolExpr); ok {
-					res, err = formatBoolExpr(ctx, nBoolExpr, 0, conf)
-				}
-				if nNullTest, ok := stmt.UpdateStmt.WhereClause.Node.(*pg_query.Node_NullTest); ok {
-					res, err = nodeformatter.FormatNullTest(ctx, nNullTest)
-				}
-				if err != nil {
-					return "", err
-				}
-				strBuilder.WriteString("\n")
-				strBuilder.WriteString("WHERE")
+			if stmt.InsertStmt.OnConflictClause.Infer.Conname != "" {
 				strBuilder.WriteString(" ")
-				strBuilder.WriteString(res)
+				strBuilder.WriteString("ON CONSTRAINT")
+				strBuilder.WriteString(" ")
+				strBuilder.WriteString(nodeformatter.FormatIdentifier(stmt.InsertStmt.OnConflictClause.Infer.Conname, conf))
 			}
-		case *pg_query.Node_DeleteStmt:
-			strBuilder.WriteString("DELETE FROM")
+		}
+
+		switch stmt.InsertStmt.OnConflictClause.Action {
+		case pg_query.OnConflictAction_ONCONFLICT_NOTHING:
+			strBuilder.WriteString("\n")
+			strBuilder.WriteString("DO NOTHING")
+		case pg_query.OnConflictAction_ONCONFLICT_UPDATE:
+			strBuilder.WriteString("\n")
+			strBuilder.WriteString("DO UPDATE SET")
+		}
+		res, err := formatSetClause(ctx, stmt.InsertStmt.OnConflictClause.TargetList, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
 
-			// output table name
-			tableName, err := nodeformatter.FormatRelation(ctx, stmt.DeleteStmt.Relation)
+		// ex) DO UPDATE SET ... WHERE users.deleted_at IS NULL
+		if stmt.InsertStmt.OnConflictClause.WhereClause != nil {
+			res, err := formatWhereClause(ctx, stmt.InsertStmt.OnConflictClause.WhereClause, 0, conf)
 			if err != nil {
 				return "", err
 			}
-			strBuilder.WriteString(tableName)
-
-			// output where clause
-			if stmt.DeleteStmt.WhereClause != nil {
-				var (
-					res string
-					err error
-				)
-				if n, ok := stmt.DeleteStmt.WhereClause.Node.(*pg_query.Node_AExpr); ok {
-					res, err = nodeformatter.FormatAExpr(ctx, n, conf)
-				}
-				if nBoolExpr, ok := stmt.DeleteStmt.WhereClause.Node.(*pg_query.Node_BoolExpr); ok {
-					res, err = formatBoolExpr(ctx, nBoolExpr, 0, conf)
-				}
-				if nNullTest, ok := stmt.DeleteStmt.WhereClause.Node.(*pg_query.Node_NullTest); ok {
-					res, err = nodeformatter.FormatNullTest(ctx, nNullTest)
-				}
-				if err != nil {
-					return "", err
-				}
-				strBuilder.WriteString("\n")
-				strBuilder.WriteString("WHERE")
-				strBuilder.WriteString(" ")
-				strBuilder.WriteString(res)
-			}
+			strBuilder.WriteString(res)
+		}
+	}
+
+	// output returning clause
+	if len(stmt.InsertStmt.ReturningList) > 0 {
+		res, err := formatTargetList(ctx, stmt.InsertStmt.ReturningList, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString("\n")
+		strBuilder.WriteString("RETURNING")
+		strBuilder.WriteString(res)
+	}
+
+	return strBuilder.String(), nil
+}
+
+func formatUpdateStmt(ctx context.Context, stmt *pg_query.Node_UpdateStmt, conf *fmtconf.Config) (string, error) {
+	var strBuilder strings.Builder
+
+	// output with clause
+	if stmt.UpdateStmt.WithClause != nil {
+		res, err := formatWithClause(ctx, stmt.UpdateStmt.WithClause, 0, conf)
+		if err != nil {
+			return "", err
 		}
+		strBuilder.WriteString(res)
+		strBuilder.WriteString("\n")
+	}
+
+	strBuilder.WriteString("UPDATE")
+
+	// output table name
+	tableName, err := nodeformatter.FormatRelation(ctx, stmt.UpdateStmt.Relation, conf)
+	if err != nil {
+		return "", err
 	}
+	strBuilder.WriteString(tableName)
+
 	strBuilder.WriteString("\n")
-	return strings.NewReplacer([]string{
-		npMarkPrefix, namedParamPrefix,
-	}...).Replace(strBuilder.String()), nil
+	strBuilder.WriteString("SET")
+
+	setClause, err := formatSetClause(ctx, stmt.UpdateStmt.TargetList, conf)
+	if err != nil {
+		return "", err
+	}
+	strBuilder.WriteString(setClause)
+
+	// output from clause
+	if len(stmt.UpdateStmt.FromClause) > 0 {
+		res, err := formatFromClause(ctx, "FROM", stmt.UpdateStmt.FromClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+	}
+
+	// output where clause
+	if stmt.UpdateStmt.WhereClause != nil {
+		res, err := formatWhereClause(ctx, stmt.UpdateStmt.WhereClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+	}
+
+	// output returning clause
+	if len(stmt.UpdateStmt.ReturningList) > 0 {
+		res, err := formatTargetList(ctx, stmt.UpdateStmt.ReturningList, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString("\n")
+		strBuilder.WriteString("RETURNING")
+		strBuilder.WriteString(res)
+	}
+
+	return strBuilder.String(), nil
+}
+
+// isBareColumnInfer reports whether the conflict target is a list of columns or a constraint, ex) (user_uuid, email)
+func isBareColumnInfer(infer *pg_query.InferClause) bool {
+	if infer.WhereClause != nil {
+		return false
+	}
+	for _, elem := range infer.IndexElems {
+		e, ok := elem.Node.(*pg_query.Node_IndexElem)
+		if !ok || e.IndexElem.Name == "" || e.IndexElem.Expr != nil || len(e.IndexElem.Collation) > 0 || len(e.IndexElem.Opclass) > 0 || len(e.IndexElem.Opclassopts) > 0 ||
+			e.IndexElem.Ordering != pg_query.SortByDir_SORTBY_DEFAULT || e.IndexElem.NullsOrdering != pg_query.SortByNulls_SORTBY_NULLS_DEFAULT {
+			return false
+		}
+	}
+	return true
+}
+
+// formatSetClause outputs the assignments of UPDATE and ON CONFLICT DO UPDATE, one per line.
+// The columns of a multiple-column assignment share one MultiAssignRef source, it is output by the first column.
+// ex) email = $1, (first_name, last_name) = ($2, $3)
+func formatSetClause(ctx context.Context, targetList []*pg_query.Node, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	for targetI, target := range targetList {
+		res, ok := target.Node.(*pg_query.Node_ResTarget)
+		if !ok {
+			continue
+		}
+
+		var names []string
+		valNode := res.ResTarget.Val
+		if ref, ok := valNode.Node.(*pg_query.Node_MultiAssignRef); ok {
+			if ref.MultiAssignRef.Colno != 1 {
+				continue
+			}
+			for _, col := range targetList[targetI : targetI+int(ref.MultiAssignRef.Ncolumns)] {
+				names = append(names, nodeformatter.FormatIdentifier(col.GetResTarget().Name, conf))
+			}
+			valNode = ref.MultiAssignRef.Source
+		}
+
+		if targetI != 0 {
+			bu.WriteString(",")
+		}
+		bu.WriteString("\n")
+		bu.WriteString(internal.GetIndent(conf))
+		if names != nil {
+			bu.WriteString("(")
+			bu.WriteString(strings.Join(names, ", "))
+			bu.WriteString(")")
+		} else {
+			bu.WriteString(nodeformatter.FormatIdentifier(res.ResTarget.Name, conf))
+		}
+		bu.WriteString(" = ")
+
+		val, err := nodeformatter.FormatExpr(ctx, valNode, 1, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(val)
+	}
+
+	return bu.String(), nil
+}
+
+func formatDeleteStmt(ctx context.Context, stmt *pg_query.Node_DeleteStmt, conf *fmtconf.Config) (string, error) {
+	var strBuilder strings.Builder
+
+	// output with clause
+	if stmt.DeleteStmt.WithClause != nil {
+		res, err := formatWithClause(ctx, stmt.DeleteStmt.WithClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+		strBuilder.WriteString("\n")
+	}
+
+	strBuilder.WriteString("DELETE FROM")
+
+	// output table name
+	tableName, err := nodeformatter.FormatRelation(ctx, stmt.DeleteStmt.Relation, conf)
+	if err != nil {
+		return "", err
+	}
+	strBuilder.WriteString(tableName)
+
+	// output using clause
+	if len(stmt.DeleteStmt.UsingClause) > 0 {
+		res, err := formatFromClause(ctx, "USING", stmt.DeleteStmt.UsingClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+	}
+
+	// output where clause
+	if stmt.DeleteStmt.WhereClause != nil {
+		res, err := formatWhereClause(ctx, stmt.DeleteStmt.WhereClause, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString(res)
+	}
+
+	// output returning clause
+	if len(stmt.DeleteStmt.ReturningList) > 0 {
+		res, err := formatTargetList(ctx, stmt.DeleteStmt.ReturningList, 0, conf)
+		if err != nil {
+			return "", err
+		}
+		strBuilder.WriteString("\n")
+		strBuilder.WriteString("RETURNING")
+		strBuilder.WriteString(res)
+	}
+
+	return strBuilder.String(), nil
 }
 
 func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, indent int, conf *fmtconf.Config) (string, error) {
 	// Handle set operations first, before checking target list
 	if stmt.SelectStmt.Op != pg_query.SetOperation_SETOP_NONE {
+		if needsSetOpDeparse(stmt.SelectStmt) {
+			return deparseSelectStmt(ctx, stmt, indent, conf)
+		}
+
 		var bu strings.Builder
 
+		// output with clause
+		if stmt.SelectStmt.WithClause != nil {
+			res, err := formatWithClause(ctx, stmt.SelectStmt.WithClause, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
+			bu.WriteString("\n")
+		}
+
 		if stmt.SelectStmt.Larg != nil {
 			leftStmt := &pg_query.Node_SelectStmt{SelectStmt: stmt.SelectStmt.Larg}
 			leftRes, err := FormatSelectStmt(ctx, leftStmt, indent, conf)
@@ -432,14 +512,33 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 			bu.WriteString(rightRes)
 		}
 
+		// ex) SELECT 1 UNION SELECT 2 ORDER BY 1 LIMIT 10
+		res, err := formatSortAndLimitClauses(ctx, stmt.SelectStmt, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+
 		return bu.String(), nil
 	}
 
-	if len(stmt.SelectStmt.TargetList) == 0 {
-		return "", nil
+	// output the whole statement by the deparser if it has clauses without dedicated formatter
+	if len(stmt.SelectStmt.TargetList) == 0 || stmt.SelectStmt.IntoClause != nil || stmt.SelectStmt.LimitOption == pg_query.LimitOption_LIMIT_OPTION_WITH_TIES {
+		return deparseSelectStmt(ctx, stmt, indent, conf)
 	}
 
 	var bu strings.Builder
+
+	// output with clause
+	if stmt.SelectStmt.WithClause != nil {
+		res, err := formatWithClause(ctx, stmt.SelectStmt.WithClause, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+		bu.WriteString("\n")
+	}
+
 	for i := 0; i < indent; i++ {
 		bu.WriteString(internal.GetIndent(conf))
 	}
@@ -449,268 +548,49 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 	if len(stmt.SelectStmt.DistinctClause) > 0 {
 		bu.WriteString(" DISTINCT")
 	}
-
-	// output column name
-	for ti, node := range stmt.SelectStmt.TargetList {
-		if ti != 0 {
-			bu.WriteString(",")
+	for di, node := range stmt.SelectStmt.DistinctClause {
+		// DISTINCT without ON has a single empty node
+		if node.Node == nil {
+			continue
 		}
-		if res, ok := node.Node.(*pg_query.Node_ResTarget); ok {
-			switch n := res.ResTarget.Val.Node.(type) {
-			case *pg_query.Node_ColumnRef:
-				field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				bu.WriteString(field)
-			case *pg_query.Node_FuncCall:
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				funcName, err := nodeformatter.FormatFuncname(ctx, n, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(funcName)
-				bu.WriteString("(")
-
-				arg, err := nodeformatter.FormatFuncCallArgs(ctx, n, indent+1, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(arg)
-
-				for sortI, order := range n.FuncCall.AggOrder {
-					if sortI == 0 {
-						bu.WriteString(" ")
-						bu.WriteString("ORDER BY")
-						bu.WriteString(" ")
-					}
-					if sortBy, ok := order.Node.(*pg_query.Node_SortBy); ok {
-						if sortBy.SortBy.Node != nil {
-							switch n := sortBy.SortBy.Node.Node.(type) {
-							case *pg_query.Node_ColumnRef:
-								if sortI != 0 {
-									bu.WriteString(",")
-									bu.WriteString("\n")
-									bu.WriteString(internal.GetIndent(conf))
-								}
-								field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-								if err != nil {
-									return "", err
-								}
-								bu.WriteString(field)
-								sortBy, err := nodeformatter.FormatSortByDir(ctx, sortBy)
-								if err != nil {
-									return "", err
-								}
-								bu.WriteString(sortBy)
-							}
-						}
-					}
-				}
-				bu.WriteString(")")
-				if n.FuncCall.Over != nil {
-					bu.WriteString(" OVER()")
-				}
-			case *pg_query.Node_SubLink:
-				if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-					res, err := FormatSelectStmt(ctx, selectStmt, indent+2, conf)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString("\n")
-					bu.WriteString(internal.GetIndent(conf))
-
-					slt, err := enumconv.SubLinkTypeToString(n.SubLink.SubLinkType)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString(slt)
-
-					bu.WriteString("(\n")
-					bu.WriteString(res)
-					bu.WriteString("\n")
-					bu.WriteString(internal.GetIndent(conf))
-					bu.WriteString(")")
-				}
-
-			case *pg_query.Node_CoalesceExpr:
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				bu.WriteString("COALESCE")
-				bu.WriteString("(")
-
-				for argI, arg := range n.CoalesceExpr.Args {
-					if argI != 0 {
-						bu.WriteString(",")
-						bu.WriteString(" ")
-					}
-					switch n := arg.Node.(type) {
-					case *pg_query.Node_ColumnRef:
-						field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(field)
-					case *pg_query.Node_SubLink:
-						if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-							res, err := FormatSelectStmt(ctx, selectStmt, indent+2, conf)
-							if err != nil {
-								return "", err
-							}
-							bu.WriteString("(\n")
-							bu.WriteString(res)
-							bu.WriteString("\n")
-							bu.WriteString(internal.GetIndent(conf))
-							bu.WriteString(")")
-						}
-					case *pg_query.Node_AConst:
-						aconst, err := nodeformatter.FormatAConst(ctx, n)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(aconst)
-					case *pg_query.Node_FuncCall:
-						funcName, err := nodeformatter.FormatFuncname(ctx, n, conf)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(funcName)
-						bu.WriteString("(")
-
-						arg, err := nodeformatter.FormatFuncCallArgs(ctx, n, indent+1, conf)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(arg)
-						bu.WriteString(")")
-					}
-				}
-
-				bu.WriteString(")")
-			case *pg_query.Node_TypeCast:
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				tc, err := nodeformatter.FormatTypeCast(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(tc)
-			case *pg_query.Node_CaseExpr:
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				caseExpr, err := nodeformatter.FormatCaseExpr(ctx, n, indent, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(caseExpr)
-			case *pg_query.Node_AConst:
-				aconst, err := nodeformatter.FormatAConst(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				bu.WriteString(aconst)
-			}
-			if res.ResTarget.Name != "" {
-				bu.WriteString(" AS ")
-				bu.WriteString(res.ResTarget.Name)
-			}
+		if di == 0 {
+			bu.WriteString(" ON (")
+		} else {
+			bu.WriteString(", ")
 		}
-	}
-
-	// output table name
-	for _, node := range stmt.SelectStmt.FromClause {
-		res, err := FormatSelectStmtFromClause(ctx, node.Node, indent, conf)
+		res, err := nodeformatter.FormatExpr(ctx, node, indent, conf)
 		if err != nil {
 			return "", err
 		}
 		bu.WriteString(res)
-	}
-
-	// output where clause
-	if stmt.SelectStmt.WhereClause != nil {
-		switch n := stmt.SelectStmt.WhereClause.Node.(type) {
-		case *pg_query.Node_AExpr:
-			res, err := nodeformatter.FormatAExpr(ctx, n, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString("\n")
-			for i := 0; i < indent; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
-			bu.WriteString("WHERE")
-			bu.WriteString(" ")
-			bu.WriteString(res)
-		case *pg_query.Node_BoolExpr:
-			res, err := formatBoolExpr(ctx, n, indent, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString("\n")
-			for i := 0; i < indent; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
-			bu.WriteString("WHERE")
-			bu.WriteString(" ")
-			bu.WriteString(res)
-		case *pg_query.Node_NullTest:
-			res, err := nodeformatter.FormatNullTest(ctx, n)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString("\n")
-			for i := 0; i < indent; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
-			bu.WriteString("WHERE")
-			bu.WriteString(" ")
-			bu.WriteString(res)
-		case *pg_query.Node_SubLink:
-			if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-				res, err := FormatSelectStmt(ctx, selectStmt, indent+1, conf)
-				if err != nil {
-					return "", err
-				}
+		if di == len(stmt.SelectStmt.DistinctClause)-1 {
+			bu.WriteString(")")
+		}
+	}
 
-				bu.WriteString("\n")
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				bu.WriteString("WHERE")
-				bu.WriteString(" ")
+	// output column name
+	res, err := formatTargetList(ctx, stmt.SelectStmt.TargetList, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	bu.WriteString(res)
 
-				slt, err := enumconv.SubLinkTypeToString(n.SubLink.SubLinkType)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(slt)
+	// output table name
+	if len(stmt.SelectStmt.FromClause) > 0 {
+		res, err := formatFromClause(ctx, "FROM", stmt.SelectStmt.FromClause, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
 
-				bu.WriteString("(\n")
-				bu.WriteString(res)
-				bu.WriteString("\n")
-				bu.WriteString(")")
-			}
+	// output where clause
+	if stmt.SelectStmt.WhereClause != nil {
+		res, err := formatWhereClause(ctx, stmt.SelectStmt.WhereClause, indent, conf)
+		if err != nil {
+			return "", err
 		}
+		bu.WriteString(res)
 	}
 
 	// output group clause
@@ -723,20 +603,11 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 			bu.WriteString(",")
 			bu.WriteString(" ")
 		}
-		switch node := gClause.Node.(type) {
-		case *pg_query.Node_ColumnRef:
-			field, err := nodeformatter.FormatColumnRefFields(ctx, node)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(field)
-		case *pg_query.Node_TypeCast:
-			tc, err := nodeformatter.FormatTypeCast(ctx, node)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(tc)
+		res, err := nodeformatter.FormatExpr(ctx, gClause, indent, conf)
+		if err != nil {
+			return "", err
 		}
+		bu.WriteString(res)
 	}
 
 	// output having clause
@@ -749,130 +620,89 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 		bu.WriteString(" ")
 
 		switch n := stmt.SelectStmt.HavingClause.Node.(type) {
-		case *pg_query.Node_AExpr:
-			res, err := nodeformatter.FormatAExpr(ctx, n, conf)
+		case *pg_query.Node_BoolExpr:
+			res, err := formatBoolExpr(ctx, n, indent, conf)
 			if err != nil {
 				return "", err
 			}
 			bu.WriteString(res)
-		case *pg_query.Node_BoolExpr:
-			res, err := formatBoolExpr(ctx, n, indent, conf)
+		default:
+			res, err := nodeformatter.FormatExpr(ctx, stmt.SelectStmt.HavingClause, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
+		}
+	}
+
+	// output window clause
+	for wi, node := range stmt.SelectStmt.WindowClause {
+		if w, ok := node.Node.(*pg_query.Node_WindowDef); ok {
+			if wi == 0 {
+				bu.WriteString("\n")
+				for i := 0; i < indent; i++ {
+					bu.WriteString(internal.GetIndent(conf))
+				}
+				bu.WriteString("WINDOW")
+				bu.WriteString(" ")
+			} else {
+				bu.WriteString(",")
+				bu.WriteString("\n")
+				for i := 0; i < indent+1; i++ {
+					bu.WriteString(internal.GetIndent(conf))
+				}
+			}
+			res, err := nodeformatter.FormatWindowDef(ctx, w.WindowDef, indent+1, conf)
 			if err != nil {
 				return "", err
 			}
+			bu.WriteString(nodeformatter.FormatIdentifier(w.WindowDef.Name, conf))
+			bu.WriteString(" AS (")
 			bu.WriteString(res)
+			bu.WriteString(")")
 		}
 	}
 
+	res, err = formatSortAndLimitClauses(ctx, stmt.SelectStmt, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	bu.WriteString(res)
+
+	return bu.String(), nil
+}
+
+// formatSortAndLimitClauses outputs ORDER BY, LIMIT, OFFSET and the locking clauses of SELECT, they follow the last operand of a set operation
+// ex) ORDER BY created_at DESC LIMIT 10 OFFSET 20 FOR UPDATE
+func formatSortAndLimitClauses(ctx context.Context, stmt *pg_query.SelectStmt, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
 	// output sort clause
-	if stmt.SelectStmt.SortClause != nil {
+	if stmt.SortClause != nil {
 		bu.WriteString("\n")
 		for i := 0; i < indent; i++ {
 			bu.WriteString(internal.GetIndent(conf))
 		}
 		bu.WriteString("ORDER BY")
 		bu.WriteString(" ")
-		for sortI, node := range stmt.SelectStmt.SortClause {
-			if sortBy, ok := node.Node.(*pg_query.Node_SortBy); ok {
-				if sortBy.SortBy.Node != nil {
-					switch n := sortBy.SortBy.Node.Node.(type) {
-					case *pg_query.Node_ColumnRef:
-						if sortI != 0 {
-							bu.WriteString(",")
-							bu.WriteString("\n")
-							bu.WriteString(internal.GetIndent(conf))
-						}
-						field, err := nodeformatter.FormatColumnRefFields(ctx, n)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(field)
-
-						sortBy, err := nodeformatter.FormatSortByDir(ctx, sortBy)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(sortBy)
-					case *pg_query.Node_TypeCast:
-						if sortI != 0 {
-							bu.WriteString(",")
-							bu.WriteString("\n")
-							bu.WriteString(internal.GetIndent(conf))
-						}
-						tc, err := nodeformatter.FormatTypeCast(ctx, n)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(tc)
-
-						sortBy, err := nodeformatter.FormatSortByDir(ctx, sortBy)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(sortBy)
-					case *pg_query.Node_FuncCall:
-						for i := 0; i < indent; i++ {
-							bu.WriteString(internal.GetIndent(conf))
-						}
-						funcName, err := nodeformatter.FormatFuncname(ctx, n, conf)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(funcName)
-						bu.WriteString("(")
-
-						arg, err := nodeformatter.FormatFuncCallArgs(ctx, n, indent, conf)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(arg)
-
-						bu.WriteString(")")
-					case *pg_query.Node_CoalesceExpr:
-						if sortI != 0 {
-							bu.WriteString(",")
-							bu.WriteString("\n")
-							bu.WriteString(internal.GetIndent(conf))
-						}
-						bu.WriteString("COALESCE")
-						bu.WriteString("(")
-
-						for argI, arg := range n.CoalesceExpr.Args {
-							if argI != 0 {
-								bu.WriteString(",")
-								bu.WriteString(" ")
-							}
-							switch argNode := arg.Node.(type) {
-							case *pg_query.Node_ColumnRef:
-								field, err := nodeformatter.FormatColumnRefFields(ctx, argNode)
-								if err != nil {
-									return "", err
-								}
-								bu.WriteString(field)
-							case *pg_query.Node_AConst:
-								aconst, err := nodeformatter.FormatAConst(ctx, argNode)
-								if err != nil {
-									return "", err
-								}
-								bu.WriteString(aconst)
-							}
-						}
-
-						bu.WriteString(")")
-
-						sortBy, err := nodeformatter.FormatSortByDir(ctx, sortBy)
-						if err != nil {
-							return "", err
-						}
-						bu.WriteString(sortBy)
-					}
+		for sortI, node := range stmt.SortClause {
+			if sortI != 0 {
+				bu.WriteString(",")
+				bu.WriteString("\n")
+				for i := 0; i < indent+1; i++ {
+					bu.WriteString(internal.GetIndent(conf))
 				}
 			}
+			res, err := nodeformatter.FormatSortBy(ctx, node, indent+1, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
 		}
 	}
 
 	// output limit clause
-	if stmt.SelectStmt.LimitCount != nil {
+	if stmt.LimitCount != nil {
 		bu.WriteString("\n")
 		for i := 0; i < indent; i++ {
 			bu.WriteString(internal.GetIndent(conf))
@@ -880,33 +710,63 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 		bu.WriteString("LIMIT")
 		bu.WriteString(" ")
 
-		switch n := stmt.SelectStmt.LimitCount.Node.(type) {
-		case *pg_query.Node_AConst:
-			res, err := nodeformatter.FormatAConst(ctx, n)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		case *pg_query.Node_ParamRef:
-			bu.WriteString("$")
-			bu.WriteString(fmt.Sprint(n.ParamRef.Number))
+		res, err := nodeformatter.FormatExpr(ctx, stmt.LimitCount, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
+
+	// output offset clause
+	if stmt.LimitOffset != nil {
+		res, err := nodeformatter.FormatExpr(ctx, stmt.LimitOffset, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString("\n")
+		for i := 0; i < indent; i++ {
+			bu.WriteString(internal.GetIndent(conf))
 		}
+		bu.WriteString("OFFSET")
+		bu.WriteString(" ")
+		bu.WriteString(res)
 	}
 
-	for _, clause := range stmt.SelectStmt.LockingClause {
+	for _, clause := range stmt.LockingClause {
 		if locking, ok := clause.Node.(*pg_query.Node_LockingClause); ok {
 			bu.WriteString("\n")
 			for i := 0; i < indent; i++ {
 				bu.WriteString(internal.GetIndent(conf))
 			}
 			switch locking.LockingClause.Strength {
+			case pg_query.LockClauseStrength_LCS_FORKEYSHARE:
+				bu.WriteString("FOR KEY SHARE")
+			case pg_query.LockClauseStrength_LCS_FORSHARE:
+				bu.WriteString("FOR SHARE")
+			case pg_query.LockClauseStrength_LCS_FORNOKEYUPDATE:
+				bu.WriteString("FOR NO KEY UPDATE")
 			case pg_query.LockClauseStrength_LCS_FORUPDATE:
 				bu.WriteString("FOR UPDATE")
 			}
 
+			for relI, rel := range locking.LockingClause.LockedRels {
+				if relI == 0 {
+					bu.WriteString(" OF ")
+				} else {
+					bu.WriteString(", ")
+				}
+				res, err := nodeformatter.DeparseNode(ctx, rel)
+				if err != nil {
+					return "", err
+				}
+				bu.WriteString(res)
+			}
+
 			switch locking.LockingClause.WaitPolicy {
 			case pg_query.LockWaitPolicy_LockWaitSkip:
 				bu.WriteString(" SKIP LOCKED")
+			case pg_query.LockWaitPolicy_LockWaitError:
+				bu.WriteString(" NOWAIT")
 			}
 		}
 	}
@@ -914,65 +774,137 @@ func FormatSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, inden
 	return bu.String(), nil
 }
 
-func FormatSelectStmtFromClause(ctx context.Context, node any, indent int, conf *fmtconf.Config) (string, error) {
+// deparseSelectStmt outputs the whole SELECT statement by the deparser, the first line is indented by indent
+func deparseSelectStmt(ctx context.Context, stmt *pg_query.Node_SelectStmt, indent int, conf *fmtconf.Config) (string, error) {
+	res, err := nodeformatter.DeparseNode(ctx, &pg_query.Node{Node: stmt})
+	if err != nil {
+		return "", err
+	}
+	return strings.Repeat(internal.GetIndent(conf), indent) + res, nil
+}
+
+// needsSetOpDeparse reports whether the set operation has an operand that needs parentheses or FETCH FIRST WITH TIES
+// ex) (SELECT 1 LIMIT 1) UNION SELECT 2, SELECT 1 EXCEPT (SELECT 2 UNION SELECT 3)
+func needsSetOpDeparse(stmt *pg_query.SelectStmt) bool {
+	if stmt.LimitOption == pg_query.LimitOption_LIMIT_OPTION_WITH_TIES {
+		return true
+	}
+	for _, arg := range []*pg_query.SelectStmt{stmt.Larg, stmt.Rarg} {
+		if arg != nil && (arg.WithClause != nil || len(arg.SortClause) > 0 || arg.LimitCount != nil || arg.LimitOffset != nil || len(arg.LockingClause) > 0) {
+			return true
+		}
+	}
+
+	// set operations are left-associative and INTERSECT binds tighter than UNION and EXCEPT
+	if r := stmt.Rarg; r != nil && r.Op != pg_query.SetOperation_SETOP_NONE &&
+		(r.Op != pg_query.SetOperation_SETOP_INTERSECT || stmt.Op == pg_query.SetOperation_SETOP_INTERSECT) {
+		return true
+	}
+	if l := stmt.Larg; l != nil && stmt.Op == pg_query.SetOperation_SETOP_INTERSECT &&
+		l.Op != pg_query.SetOperation_SETOP_NONE && l.Op != pg_query.SetOperation_SETOP_INTERSECT {
+		return true
+	}
+	return false
+}
+
+// formatTargetList outputs one target per line, used for SELECT target list and RETURNING
+func formatTargetList(ctx context.Context, targetList []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
 	var bu strings.Builder
 
-	formatTableName := func(ctx context.Context, n *pg_query.Node_RangeVar) (string, error) {
-		tName := ""
-		if n.RangeVar.Schemaname != "" {
-			tName += n.RangeVar.Schemaname
-			tName += "."
+	for ti, node := range targetList {
+		if ti != 0 {
+			bu.WriteString(",")
+		}
+		if res, ok := node.Node.(*pg_query.Node_ResTarget); ok {
+			val, err := nodeformatter.FormatExpr(ctx, res.ResTarget.Val, indent+1, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString("\n")
+			for i := 0; i < indent+1; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
+			bu.WriteString(val)
+			if res.ResTarget.Name != "" {
+				bu.WriteString(" AS ")
+				bu.WriteString(nodeformatter.FormatIdentifier(res.ResTarget.Name, conf))
+			}
+		}
+	}
+
+	return bu.String(), nil
+}
+
+func FormatSelectStmtFromClause(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	return formatFromClause(ctx, "FROM", []*pg_query.Node{node}, indent, conf)
+}
+
+// formatFromClause outputs a table list, it is also used for UPDATE ... FROM and DELETE ... USING
+// ex) FROM users u, orders o
+func formatFromClause(ctx context.Context, keyword string, nodes []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	bu.WriteString("\n")
+	for i := 0; i < indent; i++ {
+		bu.WriteString(internal.GetIndent(conf))
+	}
+	bu.WriteString(keyword)
+	bu.WriteString(" ")
+
+	for nodeI, node := range nodes {
+		itemIndent := indent
+		if nodeI != 0 {
+			itemIndent = indent + 1
+			bu.WriteString(",")
+			bu.WriteString("\n")
+			for i := 0; i < itemIndent; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
 		}
-		tName += n.RangeVar.Relname
-		if n.RangeVar.Alias != nil {
-			tName += " "
-			tName += n.RangeVar.Alias.Aliasname
+
+		res, err := formatFromItem(ctx, node, itemIndent, conf)
+		if err != nil {
+			return "", err
 		}
-		return tName, nil
+		bu.WriteString(res)
+	}
+
+	return bu.String(), nil
+}
+
+// formatFromItem outputs a table, a subquery or a join of a table list
+func formatFromItem(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	formatTableName := func(ctx context.Context, n *pg_query.Node_RangeVar) (string, error) {
+		return nodeformatter.FormatRangeVar(ctx, n.RangeVar, conf)
 	}
 
-	switch n := node.(type) {
+	switch n := node.Node.(type) {
 	case *pg_query.Node_RangeVar:
 		tableName, err := formatTableName(ctx, n)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("FROM")
-		bu.WriteString(" ")
 		bu.WriteString(tableName)
 	case *pg_query.Node_RangeFunction:
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("FROM")
-		bu.WriteString(" ")
-		for _, fn := range n.RangeFunction.Functions {
-			if list, ok := fn.Node.(*pg_query.Node_List); ok {
-				for _, item := range list.List.Items {
-					if sqlvalueFunc, ok := item.Node.(*pg_query.Node_SqlvalueFunction); ok {
-						if sqlvalueFunc.SqlvalueFunction.Op == pg_query.SQLValueFunctionOp_SVFOP_USER {
-							bu.WriteString("user")
-						}
-					}
-				}
+		if isUserRangeFunction(n) {
+			bu.WriteString("user")
+			if n.RangeFunction.Alias != nil {
+				bu.WriteString(" ")
+				bu.WriteString(nodeformatter.FormatAlias(n.RangeFunction.Alias, conf))
 			}
-		}
-		if n.RangeFunction.Alias != nil {
-			bu.WriteString(" ")
-			bu.WriteString(n.RangeFunction.Alias.Aliasname)
+		} else {
+			res, err := nodeformatter.DeparseNode(ctx, node)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
 		}
 	case *pg_query.Node_RangeSubselect:
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
+		if n.RangeSubselect.Lateral {
+			bu.WriteString("LATERAL ")
 		}
-		bu.WriteString("FROM")
-		bu.WriteString(" ")
 
 		if selectStmt, ok := n.RangeSubselect.Subquery.Node.(*pg_query.Node_SelectStmt); ok {
 			bu.WriteString("(\n")
@@ -989,13 +921,24 @@ func FormatSelectStmtFromClause(ctx context.Context, node any, indent int, conf
 
 			if n.RangeSubselect.Alias != nil {
 				bu.WriteString(" ")
-				bu.WriteString(n.RangeSubselect.Alias.Aliasname)
+				bu.WriteString(nodeformatter.FormatAlias(n.RangeSubselect.Alias, conf))
 			}
 		}
 	case *pg_query.Node_JoinExpr:
-		res, err := FormatSelectStmtFromClause(ctx, n.JoinExpr.Larg.Node, indent, conf)
+		// ex) a NATURAL JOIN b, a CROSS JOIN b, (a JOIN b ON ...) AS j, a JOIN (b JOIN c ON ...) ON ...
+		if _, ok := n.JoinExpr.Rarg.Node.(*pg_query.Node_JoinExpr); ok || n.JoinExpr.IsNatural ||
+			(n.JoinExpr.Quals == nil && len(n.JoinExpr.UsingClause) == 0) || n.JoinExpr.Alias != nil || n.JoinExpr.JoinUsingAlias != nil {
+			res, err := nodeformatter.DeparseNode(ctx, node)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
+			break
+		}
+
+		res, err := formatFromItem(ctx, n.JoinExpr.Larg, indent, conf)
 		if err != nil {
-			return "", nil
+			return "", err
 		}
 		bu.WriteString(res)
 
@@ -1037,6 +980,9 @@ func FormatSelectStmtFromClause(ctx context.Context, node any, indent int, conf
 				}
 				bu.WriteString(jt)
 				bu.WriteString(" ")
+				if nRarg.RangeSubselect.Lateral {
+					bu.WriteString("LATERAL ")
+				}
 
 				bu.WriteString("(")
 				bu.WriteString("\n")
@@ -1054,19 +1000,36 @@ func FormatSelectStmtFromClause(ctx context.Context, node any, indent int, conf
 
 				if nRarg.RangeSubselect.Alias != nil {
 					bu.WriteString(" ")
-					bu.WriteString(nRarg.RangeSubselect.Alias.Aliasname)
+					bu.WriteString(nodeformatter.FormatAlias(nRarg.RangeSubselect.Alias, conf))
 				}
 			}
+		default:
+			if conf.Join.StartIndentType == fmtconf.JOIN_START_INDENT_TYPE_ONE_SPACE {
+				bu.WriteString("\n")
+				bu.WriteString(internal.GetIndent(conf))
+			} else {
+				bu.WriteString("\n")
+			}
+
+			jt, err := enumconv.JoinTypeToString(n.JoinExpr.Jointype)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(jt)
+			bu.WriteString(" ")
+
+			res, err := nodeformatter.DeparseNode(ctx, n.JoinExpr.Rarg)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
 		}
 
-		for _, u := range n.JoinExpr.UsingClause {
+		if len(n.JoinExpr.UsingClause) > 0 {
 			bu.WriteString(" ")
 			bu.WriteString("USING")
 			bu.WriteString("(")
-			switch n := u.Node.(type) {
-			case *pg_query.Node_String_:
-				bu.WriteString(n.String_.Sval)
-			}
+			bu.WriteString(nodeformatter.FormatIdentifierList(n.JoinExpr.UsingClause, conf))
 			bu.WriteString(")")
 		}
 
@@ -1085,21 +1048,40 @@ func FormatSelectStmtFromClause(ctx context.Context, node any, indent int, conf
 			bu.WriteString(" ")
 
 			switch qualsNode := n.JoinExpr.Quals.Node.(type) {
-			case *pg_query.Node_AExpr:
-				res, err := nodeformatter.FormatAExpr(ctx, qualsNode, conf)
+			case *pg_query.Node_BoolExpr:
+				res, err := formatBoolExpr(ctx, qualsNode, 0, conf)
 				if err != nil {
 					return "", err
 				}
 				bu.WriteString(res)
-			case *pg_query.Node_BoolExpr:
-				res, err := formatBoolExpr(ctx, qualsNode, 0, conf)
+			default:
+				res, err := nodeformatter.FormatExpr(ctx, n.JoinExpr.Quals, indent+1, conf)
 				if err != nil {
 					return "", err
 				}
 				bu.WriteString(res)
 			}
 		}
+	default:
+		res, err := nodeformatter.DeparseNode(ctx, node)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
 	}
 
 	return bu.String(), nil
 }
+
+// ex) FROM user
+func isUserRangeFunction(n *pg_query.Node_RangeFunction) bool {
+	if len(n.RangeFunction.Functions) != 1 {
+		return false
+	}
+	list, ok := n.RangeFunction.Functions[0].Node.(*pg_query.Node_List)
+	if !ok || len(list.List.Items) == 0 {
+		return false
+	}
+	sqlvalueFunc, ok := list.List.Items[0].Node.(*pg_query.Node_SqlvalueFunction)
+	return ok && sqlvalueFunc.SqlvalueFunction.Op == pg_query.SQLValueFunctionOp_SVFOP_USER
+}
@@ -1034,14 +1034,1619 @@ SELECT
   CASE WHEN g.operated_by = $2 THEN 'CREATOR' ELSE 'VIEWER' END AS relationship_type,
   CASE WHEN g.operated_by = $2 THEN NULL ELSE (
     SELECT
-    gvh.gather_view_history_uuid
-  FROM gather_view_history gvh
-  WHERE gvh.gather_uuid = g.gather_uuid AND gvh.user_uuid = $2
-  LIMIT 1
-) END AS gather_history_uuid
+      gvh.gather_view_history_uuid
+    FROM gather_view_history gvh
+    WHERE gvh.gather_uuid = g.gather_uuid
+      AND gvh.user_uuid = $2
+    LIMIT 1
+  ) END AS gather_history_uuid
 FROM gather g
 WHERE g.gather_uuid = ANY($1)
   AND g.deleted_at IS NULL
+`,
+		},
+		{
+			name: "WITH",
+			sql:  `with a as (select user_uuid from users where deleted_at is null) select user_uuid from a`,
+			want: `
+WITH a AS (
+  SELECT
+    user_uuid
+  FROM users
+  WHERE deleted_at IS NULL
+)
+SELECT
+  user_uuid
+FROM a
+`,
+		},
+		{
+			name: "WITH_RECURSIVE",
+			sql:  `with recursive t(n) as (select 1 union all select n from t where n < 10) select n from t`,
+			want: `
+WITH RECURSIVE t(n) AS (
+  SELECT
+    1
+  UNION ALL
+  SELECT
+    n
+  FROM t
+  WHERE n < 10
+)
+SELECT
+  n
+FROM t
+`,
+		},
+		{
+			name: "WITH_MATERIALIZED",
+			sql:  `with a as materialized (select id from users), b as not materialized (select id from a) select id from b`,
+			want: `
+WITH a AS MATERIALIZED (
+  SELECT
+    id
+  FROM users
+),
+b AS NOT MATERIALIZED (
+  SELECT
+    id
+  FROM a
+)
+SELECT
+  id
+FROM b
+`,
+		},
+		{
+			name: "WITH_SEARCH_CYCLE",
+			sql:  `with recursive tree_path(id, parent_id) as (select t.id, t.parent_id from tree t) search breadth first by id set ordercol cycle id set is_cycle to 'Y' default 'N' using path select id from tree_path`,
+			want: `
+WITH RECURSIVE tree_path(id, parent_id) AS (
+  SELECT
+    t.id,
+    t.parent_id
+  FROM tree t
+) SEARCH BREADTH FIRST BY id SET ordercol CYCLE id SET is_cycle TO 'Y' DEFAULT 'N' USING path
+SELECT
+  id
+FROM tree_path
+`,
+		},
+		{
+			name: "WITH_SUBQUERY",
+			sql:  `select id from (with x as (select id from a) select id from x) s`,
+			want: `
+SELECT
+  id
+FROM (
+  WITH x AS (
+    SELECT
+      id
+    FROM a
+  )
+  SELECT
+    id
+  FROM x
+) s
+`,
+		},
+		{
+			name: "WITH_DELETE_INSERT",
+			sql:  `with moved as (delete from users where user_uuid = $1) insert into deleted_users (user_uuid) select user_uuid from moved`,
+			want: `
+WITH moved AS (
+  DELETE FROM users
+  WHERE user_uuid = $1
+)
+INSERT INTO deleted_users(
+  user_uuid
+) SELECT
+  user_uuid
+FROM moved
+`,
+		},
+		{
+			name: "WITH_UPDATE",
+			sql:  `with x as (select id from a) update users set name = $1 where id = $2`,
+			want: `
+WITH x AS (
+  SELECT
+    id
+  FROM a
+)
+UPDATE users
+SET
+  name = $1
+WHERE id = $2
+`,
+		},
+		{
+			name: "WITH_DELETE",
+			sql:  `with x as (select id from a) delete from users where id = $1`,
+			want: `
+WITH x AS (
+  SELECT
+    id
+  FROM a
+)
+DELETE FROM users
+WHERE id = $1
+`,
+		},
+		{
+			name: "insert: returning",
+			sql:  `insert into users (user_uuid, user_name) values ($1, $2) returning user_uuid, created_at as c`,
+			want: `
+INSERT INTO users(
+  user_uuid,
+  user_name
+) VALUES (
+  $1,
+  $2
+)
+RETURNING
+  user_uuid,
+  created_at AS c
+`,
+		},
+		{
+			name: "insert: on conflict returning",
+			sql:  `insert into users (user_uuid) values ($1) on conflict (user_uuid) do nothing returning *`,
+			want: `
+INSERT INTO users(
+  user_uuid
+) VALUES (
+  $1
+)
+ON CONFLICT(user_uuid)
+DO NOTHING
+RETURNING
+  *
+`,
+		},
+		{
+			name: "update: returning",
+			sql:  `update users set user_name = $1 where user_uuid = $2 returning user_uuid, now() as updated_at`,
+			want: `
+UPDATE users
+SET
+  user_name = $1
+WHERE user_uuid = $2
+RETURNING
+  user_uuid,
+  now() AS updated_at
+`,
+		},
+		{
+			name: "delete: returning",
+			sql:  `delete from users where user_uuid = $1 returning *`,
+			want: `
+DELETE FROM users
+WHERE user_uuid = $1
+RETURNING
+  *
+`,
+		},
+		{
+			name: "FALLBACK_OFFSET",
+			sql:  `select id from users limit 10 offset 20`,
+			want: `
+SELECT
+  id
+FROM users
+LIMIT 10
+OFFSET 20
+`,
+		},
+		{
+			name: "FALLBACK_DISTINCT_ON",
+			sql:  `select distinct on (a) a, b from t order by a, b desc nulls last`,
+			want: `
+SELECT DISTINCT ON (a)
+  a,
+  b
+FROM t
+ORDER BY a,
+  b DESC NULLS LAST
+`,
+		},
+		{
+			name: "FALLBACK_WHERE_EXPRESSION",
+			sql:  `select a from t where a between 1 and 2 and b in (1, 2) and c like 'x%'`,
+			want: `
+SELECT
+  a
+FROM t
+WHERE a BETWEEN 1 AND 2
+  AND b IN (1, 2)
+  AND c LIKE 'x%'
+`,
+		},
+		{
+			name: "FALLBACK_WHERE_NOT",
+			sql:  `select a from t where not a = 1`,
+			want: `
+SELECT
+  a
+FROM t
+WHERE NOT a = 1
+`,
+		},
+		{
+			name: "FALLBACK_TARGET_AND_FROM",
+			sql:  `select a + b as s, x is distinct from y from t tablesample system(10)`,
+			want: `
+SELECT
+  a + b AS s,
+  x IS DISTINCT FROM y
+FROM t TABLESAMPLE system(10)
+`,
+		},
+		{
+			name: "FALLBACK_TYPECAST_ARG",
+			sql:  `select (a + b)::text from t`,
+			want: `
+SELECT
+  (a + b)::text
+FROM t
+`,
+		},
+		{
+			name: "FALLBACK_INSERT_VALUES",
+			sql:  `insert into t (a, b) values ($1, current_user)`,
+			want: `
+INSERT INTO t(
+  a,
+  b
+) VALUES (
+  $1,
+  CURRENT_USER
+)
+`,
+		},
+		{
+			name: "FALLBACK_LOCKING_CLAUSE",
+			sql:  `select id from users for share of users nowait`,
+			want: `
+SELECT
+  id
+FROM users
+FOR SHARE OF users NOWAIT
+`,
+		},
+		{
+			name: "FALLBACK_VALUES",
+			sql:  `values (1), (2)`,
+			want: `
+VALUES (1), (2)
+`,
+		},
+		{
+			name: "FALLBACK_STATEMENT",
+			sql:  `create table foo (id int)`,
+			want: `
+CREATE TABLE foo (id int)
+`,
+		},
+		{
+			name: "COMMENT_LEADING_AND_TRAILING",
+			sql: `
+				-- name: GetUser :one
+				select user_name, -- the name
+					user_age
+				from users -- tbl
+				where user_uuid = $1 -- by id
+				-- end
+			`,
+			want: `
+-- name: GetUser :one
+SELECT
+  user_name, -- the name
+  user_age
+FROM users -- tbl
+WHERE user_uuid = $1 -- by id
+-- end
+`,
+		},
+		{
+			name: "COMMENT_TARGET_LIST_ITEM",
+			sql: `
+				select
+					/* identifier */
+					user_uuid,
+					-- display name
+					user_name
+				from users
+			`,
+			want: `
+SELECT
+  /* identifier */
+  user_uuid,
+  -- display name
+  user_name
+FROM users
+`,
+		},
+		{
+			name: "COMMENT_HINT",
+			sql:  `select /*+ SeqScan(users) */ user_name from users`,
+			want: `
+SELECT /*+ SeqScan(users) */
+  user_name
+FROM users
+`,
+		},
+		{
+			name: "COMMENT_MULTIPLE_TRAILING",
+			sql: `
+				update users set user_name = $1 /* new name */ -- set
+				where user_uuid = $2
+			`,
+			want: `
+UPDATE users
+SET
+  user_name = $1 /* new name */ -- set
+WHERE user_uuid = $2
+`,
+		},
+		{
+			name: "MULTIPLE_STATEMENTS",
+			sql:  `select 1; select user_name from users`,
+			want: `
+SELECT
+  1;
+
+SELECT
+  user_name
+FROM users;
+`,
+		},
+		{
+			name: "MULTIPLE_STATEMENTS_BLANK_LINES",
+			sql:  `select 1; select 2;`,
+			conf: fmtconf.NewDefaultConfig().WithStatementBlankLines(2),
+			want: `
+SELECT
+  1;
+
+
+SELECT
+  2;
+`,
+		},
+		{
+			name: "MULTIPLE_STATEMENTS_COMMENTS",
+			sql: `
+				-- first
+				select 1; -- after first
+				-- second
+				select 2
+				-- end
+			`,
+			want: `
+-- first
+SELECT
+  1; -- after first
+
+-- second
+SELECT
+  2;
+-- end
+`,
+		},
+		{
+			name: "STATEMENT_WITH_SEMICOLON",
+			sql:  `select user_name from users;`,
+			want: `
+SELECT
+  user_name
+FROM users;
+`,
+		},
+		{
+			name: "STATEMENT_SEMICOLON_ALWAYS",
+			sql:  `select user_name from users -- comment`,
+			conf: fmtconf.NewDefaultConfig().WithStatementSemicolonAlways(),
+			want: `
+SELECT
+  user_name
+FROM users; -- comment
+`,
+		},
+		{
+			name: "UPDATE_SCHEMA_AND_ALIAS",
+			sql:  `update public.users u set user_name = $1 where u.user_uuid = $2`,
+			want: `
+UPDATE public.users u
+SET
+  user_name = $1
+WHERE u.user_uuid = $2
+`,
+		},
+		{
+			name: "DELETE_ONLY",
+			sql:  `delete from only app.users where user_uuid = $1`,
+			want: `
+DELETE FROM ONLY app.users
+WHERE user_uuid = $1
+`,
+		},
+		{
+			name: "INSERT_SCHEMA_AND_ALIAS",
+			sql:  `insert into app.users as u (user_uuid) values ($1) on conflict (user_uuid) do update set user_name = u.user_name`,
+			want: `
+INSERT INTO app.users AS u(
+  user_uuid
+) VALUES (
+  $1
+)
+ON CONFLICT(user_uuid)
+DO UPDATE SET
+  user_name = u.user_name
+`,
+		},
+		{
+			name: "SELECT_CATALOG_AND_COLUMN_ALIAS",
+			sql:  `select x.a from db.public.users x(a, b) inner join only orders o on x.a = o.a`,
+			want: `
+SELECT
+  x.a
+FROM db.public.users x(a, b)
+  INNER JOIN ONLY orders o
+    ON x.a = o.a
+`,
+		},
+		{
+			name: "SELECT_SUBQUERY_COLUMN_ALIAS",
+			sql:  `select s.n from (select 1) s(n)`,
+			want: `
+SELECT
+  s.n
+FROM (
+  SELECT
+    1
+) s(n)
+`,
+		},
+		{
+			name: "UPDATE_FROM",
+			sql:  `update users u set user_name = n.name from new_names n where u.user_uuid = n.user_uuid`,
+			want: `
+UPDATE users u
+SET
+  user_name = n.name
+FROM new_names n
+WHERE u.user_uuid = n.user_uuid
+`,
+		},
+		{
+			name: "UPDATE_FROM_JOIN_AND_LIST",
+			sql:  `update users u set user_age = a.age from user_age a inner join user_address ad on a.user_uuid = ad.user_uuid, (select user_uuid from vips) v where u.user_uuid = a.user_uuid and v.user_uuid = u.user_uuid returning u.user_uuid`,
+			want: `
+UPDATE users u
+SET
+  user_age = a.age
+FROM user_age a
+  INNER JOIN user_address ad
+    ON a.user_uuid = ad.user_uuid,
+  (
+    SELECT
+      user_uuid
+    FROM vips
+  ) v
+WHERE u.user_uuid = a.user_uuid
+  AND v.user_uuid = u.user_uuid
+RETURNING
+  u.user_uuid
+`,
+		},
+		{
+			name: "DELETE_USING",
+			sql:  `delete from users u using banned b where u.user_uuid = b.user_uuid`,
+			want: `
+DELETE FROM users u
+USING banned b
+WHERE u.user_uuid = b.user_uuid
+`,
+		},
+		{
+			name: "DELETE_USING_SUBQUERY",
+			sql:  `delete from users using (select user_uuid from banned) b where users.user_uuid = b.user_uuid`,
+			want: `
+DELETE FROM users
+USING (
+  SELECT
+    user_uuid
+  FROM banned
+) b
+WHERE users.user_uuid = b.user_uuid
+`,
+		},
+		{
+			name: "SELECT_FROM_LIST",
+			sql:  `select u.user_name from users u, orders o where u.user_uuid = o.user_uuid`,
+			want: `
+SELECT
+  u.user_name
+FROM users u,
+  orders o
+WHERE u.user_uuid = o.user_uuid
+`,
+		},
+		{
+			name: "UPDATE_WHERE_EXISTS",
+			sql:  `update users u set user_name = $1 where exists (select 1 from banned b where b.user_uuid = u.user_uuid) and u.active`,
+			want: `
+UPDATE users u
+SET
+  user_name = $1
+WHERE EXISTS(
+  SELECT
+    1
+  FROM banned b
+  WHERE b.user_uuid = u.user_uuid
+)
+  AND u.active
+`,
+		},
+		{
+			name: "UPDATE_WHERE_CURRENT_OF",
+			sql:  `update users set user_name = $1 where current of users_cursor`,
+			want: `
+UPDATE users
+SET
+  user_name = $1
+WHERE CURRENT OF users_cursor
+`,
+		},
+		{
+			name: "DELETE_WHERE_IN_SUBQUERY",
+			sql:  `delete from users where user_uuid = $1 and user_uuid not in (select user_uuid from admins where active)`,
+			want: `
+DELETE FROM users
+WHERE user_uuid = $1
+  AND user_uuid NOT IN(
+    SELECT
+      user_uuid
+    FROM admins
+    WHERE active
+  )
+`,
+		},
+		{
+			name: "DELETE_WHERE_BOOLEAN_COLUMN",
+			sql:  `delete from sessions where expired`,
+			want: `
+DELETE FROM sessions
+WHERE expired
+`,
+		},
+		{
+			name: "SELECT_WHERE_ANY_SUBQUERY",
+			sql:  `select user_uuid from users where user_age > all (select user_age from admins) or user_uuid in (select user_uuid from vips)`,
+			want: `
+SELECT
+  user_uuid
+FROM users
+WHERE user_age > ALL(
+  SELECT
+    user_age
+  FROM admins
+)
+  OR user_uuid IN(
+    SELECT
+      user_uuid
+    FROM vips
+  )
+`,
+		},
+		{
+			name: "NESTED_WHERE_EXISTS",
+			sql:  `select s.user_uuid from (select user_uuid from users u where exists (select 1 from vips v where v.user_uuid = u.user_uuid)) s`,
+			want: `
+SELECT
+  s.user_uuid
+FROM (
+  SELECT
+    user_uuid
+  FROM users u
+  WHERE EXISTS(
+    SELECT
+      1
+    FROM vips v
+    WHERE v.user_uuid = u.user_uuid
+  )
+) s
+`,
+		},
+		{
+			name: "UPDATE_SET_EXPRESSION",
+			sql:  `update users set login_count = login_count + 1, user_name = lower(replace($1, ' ', '_')), updated_at = now() where user_uuid = $2`,
+			want: `
+UPDATE users
+SET
+  login_count = login_count + 1,
+  user_name = lower(replace($1, ' ', '_')),
+  updated_at = now()
+WHERE user_uuid = $2
+`,
+		},
+		{
+			name: "ON_CONFLICT_SET_EXPRESSION",
+			sql:  `insert into counters (counter_key, counter_value) values ($1, 1) on conflict (counter_key) do update set counter_value = counters.counter_value + excluded.counter_value, updated_at = coalesce($2, now())`,
+			want: `
+INSERT INTO counters(
+  counter_key,
+  counter_value
+) VALUES (
+  $1,
+  1
+)
+ON CONFLICT(counter_key)
+DO UPDATE SET
+  counter_value = counters.counter_value + EXCLUDED.counter_value,
+  updated_at = COALESCE($2, now())
+`,
+		},
+		{
+			name: "SELECT_NESTED_EXPRESSION",
+			sql:  `select (price - discount) * quantity as total, price - (discount - 1) as net, case when quantity > 10 then price * 0.9 else price end as unit_price from order_items where (price + tax) > $1 order by (price - discount) * quantity desc, lower(item_name)`,
+			want: `
+SELECT
+  (price - discount) * quantity AS total,
+  price - (discount - 1) AS net,
+  CASE WHEN quantity > 10 THEN price * 0.9 ELSE price END AS unit_price
+FROM order_items
+WHERE price + tax > $1
+ORDER BY (price - discount) * quantity DESC,
+  lower(item_name)
+`,
+		},
+		{
+			name: "FUNC_ARG_SUBQUERY",
+			sql:  `select coalesce((select max(score) from scores s where s.user_uuid = u.user_uuid), 0) as max_score from users u`,
+			want: `
+SELECT
+  COALESCE((
+    SELECT
+      max(score)
+    FROM scores s
+    WHERE s.user_uuid = u.user_uuid
+  ), 0) AS max_score
+FROM users u
+`,
+		},
+		{
+			name: "WINDOW_FUNCTION_PARTITION_ORDER",
+			sql:  `select user_uuid, row_number() over (partition by team_uuid, role order by created_at desc nulls last, user_uuid) as rank from users`,
+			want: `
+SELECT
+  user_uuid,
+  row_number() OVER(PARTITION BY team_uuid, role ORDER BY created_at DESC NULLS LAST, user_uuid) AS rank
+FROM users
+`,
+		},
+		{
+			name: "WINDOW_FUNCTION_FRAME",
+			sql:  `select sum(amount) over (order by paid_at rows between 6 preceding and current row) as weekly, avg(amount) over (order by paid_at range between interval '1 day' preceding and unbounded following exclude current row) as daily, sum(amount) over (partition by user_uuid groups unbounded preceding exclude ties) as total from payments`,
+			want: `
+SELECT
+  sum(amount) OVER(ORDER BY paid_at ROWS BETWEEN 6 PRECEDING AND CURRENT ROW) AS weekly,
+  avg(amount) OVER(ORDER BY paid_at RANGE BETWEEN '1 day'::interval PRECEDING AND UNBOUNDED FOLLOWING EXCLUDE CURRENT ROW) AS daily,
+  sum(amount) OVER(PARTITION BY user_uuid GROUPS UNBOUNDED PRECEDING EXCLUDE TIES) AS total
+FROM payments
+`,
+		},
+		{
+			name: "WINDOW_CLAUSE",
+			sql:  `select user_uuid, rank() over w as score_rank, lag(score) over (w rows 1 preceding) as prev_score from scores where deleted_at is null window w as (partition by game_uuid order by score desc), w2 as (w) order by score_rank`,
+			want: `
+SELECT
+  user_uuid,
+  rank() OVER w AS score_rank,
+  lag(score) OVER(w ROWS 1 PRECEDING) AS prev_score
+FROM scores
+WHERE deleted_at IS NULL
+WINDOW w AS (PARTITION BY game_uuid ORDER BY score DESC),
+  w2 AS (w)
+ORDER BY score_rank
+`,
+		},
+		{
+			name: "AGGREGATE_FILTER",
+			sql:  `select count(*) filter (where deleted_at is null) as active, count(*) filter (where role = 'admin' and deleted_at is null) over (partition by team_uuid) as admins from users`,
+			want: `
+SELECT
+  count(*) FILTER(WHERE deleted_at IS NULL) AS active,
+  count(*) FILTER(WHERE role = 'admin' AND deleted_at IS NULL) OVER(PARTITION BY team_uuid) AS admins
+FROM users
+`,
+		},
+		{
+			name: "FUNC_NAME_ANY_FUNCTION",
+			sql:  `select max(score), SUM(score), lower(user_name), myschema.calc_score(score, $1), pg_catalog.upper(user_name) from scores`,
+			want: `
+SELECT
+  max(score),
+  sum(score),
+  lower(user_name),
+  myschema.calc_score(score, $1),
+  pg_catalog.upper(user_name)
+FROM scores
+`,
+		},
+		{
+			name: "FUNC_NAME_TYPE_CASE_UPPER_CATALOG",
+			sql:  `select max(score), string_agg(user_name, ','), myschema.calc_score(score), calc_rank(score), other.calc_rank(score), myschema.now() from scores where date_trunc('day', created_at) = current_date`,
+			conf: fmtconf.NewDefaultConfig().WithFuncNameTypeCaseUpper().WithFuncNames("myschema.calc_score", "calc_rank"),
+			want: `
+SELECT
+  MAX(score),
+  STRING_AGG(user_name, ','),
+  myschema.CALC_SCORE(score),
+  CALC_RANK(score),
+  other.CALC_RANK(score),
+  myschema.now()
+FROM scores
+WHERE DATE_TRUNC('day', created_at) = CURRENT_DATE
+`,
+		},
+		{
+			name: "AGGREGATE_MODIFIERS",
+			sql:  `select count(distinct user_uuid), count(*), string_agg(distinct user_name, ',' order by user_name desc), percentile_cont(0.5) within group (order by score), mode() within group (order by score desc) filter (where score > 0), concat_ws(',', variadic $1::text[]), make_interval(days => $2) from scores group by game_uuid having count(distinct user_uuid) > 1 order by sum(score order by created_at)`,
+			want: `
+SELECT
+  count(DISTINCT user_uuid),
+  count(*),
+  string_agg(DISTINCT user_name, ',' ORDER BY user_name DESC),
+  percentile_cont(0.5) WITHIN GROUP(ORDER BY score),
+  mode() WITHIN GROUP(ORDER BY score DESC) FILTER(WHERE score > 0),
+  concat_ws(',', VARIADIC $1::text[]),
+  make_interval(days => $2)
+FROM scores
+GROUP BY game_uuid
+HAVING count(DISTINCT user_uuid) > 1
+ORDER BY sum(score ORDER BY created_at)
+`,
+		},
+		{
+			name: "AGGREGATE_MODIFIERS_IN_CLAUSES",
+			sql:  `update teams t set member_names = (select string_agg(u.user_name, ',' order by u.user_name) from users u where u.team_uuid = t.team_uuid), member_count = coalesce(array_length(array_agg(distinct $1::uuid[]), 1), 0) where exists (select 1 from users u where u.team_uuid = t.team_uuid having count(distinct u.role) > 1)`,
+			want: `
+UPDATE teams t
+SET
+  member_names = (
+    SELECT
+      string_agg(u.user_name, ',' ORDER BY u.user_name)
+    FROM users u
+    WHERE u.team_uuid = t.team_uuid
+  ),
+  member_count = COALESCE(array_length(array_agg(DISTINCT $1::uuid[]), 1), 0)
+WHERE EXISTS(
+  SELECT
+    1
+  FROM users u
+  WHERE u.team_uuid = t.team_uuid
+  HAVING count(DISTINCT u.role) > 1
+)
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_SELECT",
+			sql:  `WITH active AS (SELECT user_uuid FROM users WHERE deleted_at IS NULL) SELECT DISTINCT u.user_uuid, COUNT(*) FILTER (WHERE o.status = 'paid') OVER (PARTITION BY u.team_uuid) AS paid, CASE WHEN u.role = 'admin' THEN TRUE ELSE NULL END AS is_admin, o.amount::numeric FROM users u INNER JOIN orders o ON u.user_uuid = o.user_uuid AND o.deleted_at IS NULL LEFT JOIN teams t USING (team_uuid) WHERE u.user_uuid IN (SELECT user_uuid FROM active) AND NOT EXISTS (SELECT 1 FROM bans b WHERE b.user_uuid = u.user_uuid) AND u.age BETWEEN 1 AND 10 GROUP BY u.user_uuid HAVING SUM(o.amount) > 0 ORDER BY u.user_uuid DESC NULLS LAST LIMIT 10 OFFSET 5 FOR UPDATE SKIP LOCKED`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+with active as (
+  select
+    user_uuid
+  from users
+  where deleted_at is null
+)
+select distinct
+  u.user_uuid,
+  count(*) filter(where o.status = 'paid') over(partition by u.team_uuid) as paid,
+  case when u.role = 'admin' then true else null end as is_admin,
+  o.amount::numeric
+from users u
+  inner join orders o
+    on u.user_uuid = o.user_uuid
+  and o.deleted_at is null
+  left join teams t using(team_uuid)
+where u.user_uuid in(
+  select
+    user_uuid
+  from active
+)
+  and not exists(
+    select
+      1
+    from bans b
+    where b.user_uuid = u.user_uuid
+  )
+  and u.age between 1 and 10
+group by u.user_uuid
+having sum(o.amount) > 0
+order by u.user_uuid desc nulls last
+limit 10
+offset 5
+for update skip locked
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_SET_OPERATION",
+			sql:  `SELECT user_uuid FROM users UNION ALL SELECT user_uuid FROM admins WINDOW w AS (ORDER BY user_uuid)`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+select
+  user_uuid
+from users
+union all
+select
+  user_uuid
+from admins
+window w as (order by user_uuid)
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_INSERT",
+			sql:  `INSERT INTO users AS u (user_uuid, user_name, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP) ON CONFLICT (user_uuid) DO UPDATE SET user_name = EXCLUDED.user_name WHERE u.deleted_at IS NULL RETURNING u.user_uuid`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+insert into users as u(
+  user_uuid,
+  user_name,
+  created_at
+) values (
+  $1,
+  $2,
+  current_timestamp
+)
+on conflict(user_uuid)
+do update set
+  user_name = excluded.user_name
+where u.deleted_at is null
+returning
+  u.user_uuid
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_UPDATE_DELETE",
+			sql:  `UPDATE users SET login_count = login_count + 1 FROM teams t WHERE users.team_uuid = t.team_uuid AND t.name IS NOT NULL RETURNING users.user_uuid; DELETE FROM sessions USING users u WHERE sessions.user_uuid = u.user_uuid AND u.user_uuid = ANY($1)`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+update users
+set
+  login_count = login_count + 1
+from teams t
+where users.team_uuid = t.team_uuid
+  and t.name is not null
+returning
+  users.user_uuid;
+
+delete from sessions
+using users u
+where sessions.user_uuid = u.user_uuid
+  and u.user_uuid = any($1);
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_DEPARSE_FALLBACK",
+			sql:  `CREATE TABLE users (user_uuid uuid PRIMARY KEY, name text NOT NULL DEFAULT 'none')`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+create table users (user_uuid uuid primary key, name text not null default 'none')
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_FUNC_NAME_UPPER",
+			sql:  `SELECT LEFT(user_name, 1), NOW(), user_name IS DISTINCT FROM $1 FROM users`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower().WithFuncNameTypeCaseUpper(),
+			want: `
+select
+  LEFT(user_name, 1),
+  NOW(),
+  user_name is distinct from $1
+from users
+`,
+		},
+		{
+			name: "KEYWORD_CASE_PRESERVE",
+			sql:  `Select u.user_uuid AS uuid, COUNT(*) From users u Inner Join orders o on u.user_uuid = o.user_uuid where u.name is not NULL Group By u.user_uuid`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCasePreserve(),
+			want: `
+Select
+  u.user_uuid AS uuid,
+  count(*)
+From users u
+  Inner Join orders o
+    on u.user_uuid = o.user_uuid
+where u.name is not NULL
+Group By u.user_uuid
+`,
+		},
+		{
+			name: "QUOTED_IDENTIFIER",
+			sql:  `select "User"."UserName", u."order", "select" from "User" u where u."group" = $1 and "Status" = 'active'`,
+			want: `
+SELECT
+  "User"."UserName",
+  u."order",
+  "select"
+FROM "User" u
+WHERE u."group" = $1
+  AND "Status" = 'active'
+`,
+		},
+		{
+			name: "QUOTED_IDENTIFIER_INSERT_UPDATE",
+			sql:  `insert into "Orders"("OrderID", "desc") values ($1, $2) on conflict ("OrderID") do update set "desc" = excluded."desc"; update "Users" set "Name" = $1 where "ID" = $2`,
+			want: `
+INSERT INTO "Orders"(
+  "OrderID",
+  "desc"
+) VALUES (
+  $1,
+  $2
+)
+ON CONFLICT("OrderID")
+DO UPDATE SET
+  "desc" = EXCLUDED."desc";
+
+UPDATE "Users"
+SET
+  "Name" = $1
+WHERE "ID" = $2;
+`,
+		},
+		{
+			name: "QUOTED_IDENTIFIER_CTE_FUNC_NAME",
+			sql:  `with "Active"("ID") as (select id from users) select "My Func"(a.id), myschema."Calc"(1), left(a.name, 1) from "Active" a`,
+			want: `
+WITH "Active"("ID") AS (
+  SELECT
+    id
+  FROM users
+)
+SELECT
+  "My Func"(a.id),
+  myschema."Calc"(1),
+  left(a.name, 1)
+FROM "Active" a
+`,
+		},
+		{
+			name: "IDENTIFIER_CASE_PRESERVE",
+			sql:  `select UserUuid, Name from Users where UserUuid = :UserUuid`,
+			conf: fmtconf.NewDefaultConfig().WithIdentifierCasePreserve(),
+			want: `
+SELECT
+  UserUuid,
+  Name
+FROM Users
+WHERE UserUuid = :UserUuid
+`,
+		},
+		{
+			name: "QUOTE_IDENTIFIERS_ALWAYS",
+			sql:  `select u.user_uuid, "Name", count(*) from users u where u.user_uuid = $1`,
+			conf: fmtconf.NewDefaultConfig().WithQuoteIdentifiersAlways(),
+			want: `
+SELECT
+  "u"."user_uuid",
+  "Name",
+  count(*)
+FROM "users" "u"
+WHERE "u"."user_uuid" = $1
+`,
+		},
+		{
+			name: "LITERAL_AS_WRITTEN",
+			sql:  `select 'O''Reilly', E'it\'s\n', $$it's$$, $tag$a$tag$, B'0101', X'1F', 0x1F, 1.5e3, TRUE, false, null from users where name != 'O''Reilly'`,
+			want: `
+SELECT
+  'O''Reilly',
+  E'it\'s\n',
+  $$it's$$,
+  $tag$a$tag$,
+  B'0101',
+  X'1F',
+  0x1F,
+  1.5e3,
+  TRUE,
+  false,
+  NULL
+FROM users
+WHERE name != 'O''Reilly'
+`,
+		},
+		{
+			name: "LITERAL_AS_WRITTEN_INSERT",
+			sql:  `insert into users(name, memo) values ('it''s', e'a\\b') on conflict (name) do update set memo = 'Don''t'`,
+			want: `
+INSERT INTO users(
+  name,
+  memo
+) VALUES (
+  'it''s',
+  e'a\\b'
+)
+ON CONFLICT(name)
+DO UPDATE SET
+  memo = 'Don''t'
+`,
+		},
+		{
+			name: "TYPE_CAST_TYPE_NAME",
+			sql:  `select a::varchar(255), b::numeric(10,2), c::timestamp(3) with time zone, d::pg_catalog.int4, e::int[][], f::interval day to second, g::"char", h::bit varying(5), i::double precision, j::public."Status"[], interval '1' day from users`,
+			want: `
+SELECT
+  a::varchar(255),
+  b::numeric(10, 2),
+  c::timestamp(3) with time zone,
+  d::pg_catalog.int4,
+  e::int[][],
+  f::interval day to second,
+  g::"char",
+  h::bit varying(5),
+  i::double precision,
+  j::public."Status"[],
+  '1'::interval day
+FROM users
+`,
+		},
+		{
+			name: "TYPE_CAST_STYLE_CAST",
+			sql:  `select created_at::date, (-1)::int, $1::uuid[] from users where updated_at > now() - '3 months'::interval`,
+			conf: fmtconf.NewDefaultConfig().WithTypeCastStyleCast(),
+			want: `
+SELECT
+  CAST(created_at AS date),
+  CAST(-1 AS int),
+  CAST($1 AS uuid[])
+FROM users
+WHERE updated_at > now() - CAST('3 months' AS interval)
+`,
+		},
+		{
+			name: "PLACEHOLDER_COLON_AS_WRITTEN",
+			sql:  `select tags[1:2], 'a:b' from users where user_uuid = :userUUID and ids = any(:ids::uuid[]) limit :limit`,
+			want: `
+SELECT
+  tags[1:2],
+  'a:b'
+FROM users
+WHERE user_uuid = :userUUID
+  AND ids = ANY(:ids::uuid[])
+LIMIT :limit
+`,
+		},
+		{
+			name: "PLACEHOLDER_DIALECTS",
+			sql:  `select name from users where user_uuid=@userUUID and name = sqlc.narg('name') and id = any(sqlc.slice(ids)) and org_id = {{.OrgID}} order by {{ .Sort }}`,
+			conf: fmtconf.NewDefaultConfig().WithPlaceholderDialects(fmtconf.PLACEHOLDER_DIALECT_AT, fmtconf.PLACEHOLDER_DIALECT_SQLC, fmtconf.PLACEHOLDER_DIALECT_TEMPLATE),
+			want: `
+SELECT
+  name
+FROM users
+WHERE user_uuid = @userUUID
+  AND name = sqlc.narg('name')
+  AND id = ANY(sqlc.slice(ids))
+  AND org_id = {{.OrgID}}
+ORDER BY {{ .Sort }}
+`,
+		},
+		{
+			name: "PLACEHOLDER_QUESTION",
+			sql:  `select name from users where user_uuid=? and name = ? limit ?`,
+			conf: fmtconf.NewDefaultConfig().WithPlaceholderDialects(fmtconf.PLACEHOLDER_DIALECT_QUESTION),
+			want: `
+SELECT
+  name
+FROM users
+WHERE user_uuid = ?
+  AND name = ?
+LIMIT ?
+`,
+		},
+		{
+			name: "SPRINTF_VERB",
+			sql:  `select * from %s where user_uuid = %[1]v and name like '%s%%' and org_uuid = :org_uuid limit %d`,
+			want: `
+SELECT
+  *
+FROM %s
+WHERE user_uuid = %[1]v
+  AND name LIKE '%s%%'
+  AND org_uuid = :org_uuid
+LIMIT %d
+`,
+		},
+		{
+			name: "TEMPLATE_ACTION_WITHOUT_DIALECT",
+			sql:  `select name from {{ .Table }} t where t.id = :id and t.rate % 2 = 0 order by {{.Sort}}`,
+			want: `
+SELECT
+  name
+FROM {{ .Table }} t
+WHERE t.id = :id
+  AND t.rate % 2 = 0
+ORDER BY {{.Sort}}
+`,
+		},
+		{
+			name: "EXPRESSION_KINDS",
+			sql:  `select a not in ($$x$$, B'101'), c like 'x!%' escape '!', c not similar to 'z' escape '!', d is not distinct from e, nullif(a, ''), a is not true, (array[1,2])[1:2], (f(x)).a, (t.col).*, tags[1], a = all($1), a operator(pg_catalog.+) b from t where b between (select max(id) from u) and 2`,
+			want: `
+SELECT
+  a NOT IN ($$x$$, B'101'),
+  c LIKE 'x!%' ESCAPE '!',
+  c NOT SIMILAR TO 'z' ESCAPE '!',
+  d IS NOT DISTINCT FROM e,
+  NULLIF(a, ''),
+  a IS NOT TRUE,
+  (ARRAY[1, 2])[1:2],
+  (f(x)).a,
+  (t.col).*,
+  tags[1],
+  a = ALL($1),
+  a OPERATOR(pg_catalog.+) b
+FROM t
+WHERE b BETWEEN (
+  SELECT
+    max(id)
+  FROM u
+) AND 2
+`,
+		},
+		{
+			name: "EXPRESSION_KINDS_WITH_SETTINGS",
+			sql:  `select a in (x::int, now()), -f::int, nullif(a, b) is unknown, (f(x)).a from t where d is distinct from e`,
+			conf: fmtconf.NewDefaultConfig().WithQuoteIdentifiersAlways().WithTypeCastStyleCast().WithFuncNameTypeCaseUpper(),
+			want: `
+SELECT
+  "a" IN (CAST("x" AS int), NOW()),
+  -CAST("f" AS int),
+  NULLIF("a", "b") IS UNKNOWN,
+  (f("x"))."a"
+FROM "t"
+WHERE "d" IS DISTINCT FROM "e"
+`,
+		},
+		{
+			name: "UPDATE_MULTIPLE_COLUMN_ASSIGNMENT",
+			sql:  `update users set (first_name, last_name) = ($1, $2), email = $3, (a) = row(1), (b, c) = (select x, y from t where id = 1) where id = $4`,
+			want: `
+UPDATE users
+SET
+  (first_name, last_name) = ($1, $2),
+  email = $3,
+  (a) = ROW(1),
+  (b, c) = (
+    SELECT
+      x,
+      y
+    FROM t
+    WHERE id = 1
+  )
+WHERE id = $4
+`,
+		},
+		{
+			name: "ON_CONFLICT_MULTIPLE_COLUMN_ASSIGNMENT",
+			sql:  `insert into t (a, b) values (1, 2) on conflict (a) do update set (a, b) = (excluded.a, excluded.b)`,
+			want: `
+INSERT INTO t(
+  a,
+  b
+) VALUES (
+  1,
+  2
+)
+ON CONFLICT(a)
+DO UPDATE SET
+  (a, b) = (EXCLUDED.a, EXCLUDED.b)
+`,
+		},
+		{
+			name: "GROUPING_SETS",
+			sql:  `select team_id, role, count(*) from users group by rollup(team_id, (role, x)), cube(a), grouping sets ((team_id), ())`,
+			want: `
+SELECT
+  team_id,
+  role,
+  count(*)
+FROM users
+GROUP BY ROLLUP(team_id, (role, x)), CUBE(a), GROUPING SETS (team_id, ())
+`,
+		},
+		{
+			name: "GROUPING_SETS_KEYWORD_CASE_LOWER",
+			sql:  `select team_id, count(*) from users group by rollup(team_id), cube(a), grouping sets ((team_id), ())`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+select
+  team_id,
+  count(*)
+from users
+group by rollup(team_id), cube(a), grouping sets (team_id, ())
+`,
+		},
+		{
+			name: "SET_OPERATION_WITH_ORDER_BY_AND_LIMIT",
+			sql:  `select user_uuid, created_at from users union all select user_uuid, created_at from admins order by created_at desc limit 10 offset 20`,
+			want: `
+SELECT
+  user_uuid,
+  created_at
+FROM users
+UNION ALL
+SELECT
+  user_uuid,
+  created_at
+FROM admins
+ORDER BY created_at DESC
+LIMIT 10
+OFFSET 20
+`,
+		},
+		{
+			name: "SUBQUERY_SET_OPERATION_WITH_ORDER_BY",
+			sql:  `select a from t where a in (select 1 union select 2 order by 1)`,
+			want: `
+SELECT
+  a
+FROM t
+WHERE a IN(
+  SELECT
+    1
+  UNION
+  SELECT
+    2
+  ORDER BY 1
+)
+`,
+		},
+		{
+			name: "SET_OPERATION_WITH_PARENTHESIZED_OPERAND_IS_DEPARSED",
+			sql:  `(select 1 order by 1 limit 1) union all (select 2)`,
+			want: `
+(SELECT 1 ORDER BY 1 LIMIT 1) UNION ALL SELECT 2
+`,
+		},
+		{
+			name: "SET_OPERATION_WITH_NESTED_SET_OPERATION_IS_DEPARSED",
+			sql:  `select a from t union (select b from u union select c from v)`,
+			want: `
+SELECT a FROM t UNION (SELECT b FROM u UNION SELECT c FROM v)
+`,
+		},
+		{
+			name: "NATURAL_AND_CROSS_JOIN_ARE_DEPARSED",
+			sql:  `select * from t where id in (select id from a natural join b) and exists (select 1 from a cross join b) and exists (select 1 from t cross join lateral unnest(t.x) as u(y))`,
+			want: `
+SELECT
+  *
+FROM t
+WHERE id IN(
+  SELECT
+    id
+  FROM a NATURAL JOIN b
+)
+  AND EXISTS(
+    SELECT
+      1
+    FROM a CROSS JOIN b
+  )
+  AND EXISTS(
+    SELECT
+      1
+    FROM t CROSS JOIN LATERAL unnest(t.x) u(y)
+  )
+`,
+		},
+		{
+			name: "INSERT_MULTIPLE_ROWS_IS_DEPARSED",
+			sql:  `insert into t (a) values (1), (2) on conflict do nothing returning a`,
+			want: `
+INSERT INTO t(
+  a
+) VALUES (1), (2)
+ON CONFLICT
+DO NOTHING
+RETURNING
+  a
+`,
+		},
+		{
+			name: "INSERT_DEFAULT_VALUES_IS_DEPARSED",
+			sql:  `insert into t default values`,
+			want: `
+INSERT INTO t DEFAULT VALUES
+`,
+		},
+		{
+			name: "PARENTHESIZED_ARRAY_SUBSCRIPT",
+			sql:  `select (array[1,2])[1]`,
+			want: `
+SELECT
+  (ARRAY[1, 2])[1]
+`,
+		},
+		{
+			name: "PARENTHESIZED_CONDITION_FIRST",
+			sql:  `select * from t where a = 1 and b = 2 or c`,
+			want: `
+SELECT
+  *
+FROM t
+WHERE (
+  a = 1
+    AND b = 2
+)
+  OR c
+`,
+		},
+		{
+			name: "PARENTHESIZED_CONDITION_IN_SUBQUERY",
+			sql:  `select * from t where exists (select 1 from t where a and (b or c))`,
+			want: `
+SELECT
+  *
+FROM t
+WHERE EXISTS(
+  SELECT
+    1
+  FROM t
+  WHERE a
+    AND (
+      b
+        OR c
+    )
+)
+`,
+		},
+		{
+			name: "NESTED_PARENTHESIZED_CONDITION_IN_SUBQUERY",
+			sql:  `select * from t where (a or b) and exists (select 1 from u where (x or y) and z and (p or (q and r)))`,
+			want: `
+SELECT
+  *
+FROM t
+WHERE (
+  a
+    OR b
+)
+  AND EXISTS(
+    SELECT
+      1
+    FROM u
+    WHERE (
+      x
+        OR y
+    )
+      AND z
+      AND (
+        p
+          OR (
+            q
+              AND r
+          )
+      )
+  )
+`,
+		},
+		{
+			name: "WITH_CYCLE_WITHOUT_MARK_VALUES",
+			sql:  `with recursive t(n) as (select 1) cycle n set is_cycle using path select * from t`,
+			want: `
+WITH RECURSIVE t(n) AS (
+  SELECT
+    1
+) CYCLE n SET is_cycle USING path
+SELECT
+  *
+FROM t
+`,
+		},
+		{
+			name: "WITH_INSERT_MULTI_LINE_STRING",
+			sql:  "with x as (insert into t (a) values ('line1\nline2') returning a) select a from x",
+			want: `
+WITH x AS (
+  INSERT INTO t(
+    a
+  ) VALUES (
+    'line1
+line2'
+  )
+  RETURNING
+    a
+)
+SELECT
+  a
+FROM x
+`,
+		},
+		{
+			name: "KEYWORD_CASE_UPPER_DEPARSED_KEYWORDS",
+			sql:  `select collation for (name), current_user, localtime(3), extract(year from created_at), x::timestamp with time zone, is_active = true from users`,
+			want: `
+SELECT
+  COLLATION FOR (name),
+  CURRENT_USER,
+  LOCALTIME(3),
+  EXTRACT ('year' FROM created_at),
+  x::timestamp with time zone,
+  is_active = true
+FROM users
+`,
+		},
+		{
+			name: "KEYWORD_CASE_LOWER_DEPARSED_KEYWORDS",
+			sql:  `SELECT COLLATION FOR (name), CURRENT_USER, LOCALTIME(3), EXTRACT(YEAR FROM created_at) FROM users`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCaseLower(),
+			want: `
+select
+  collation for (name),
+  current_user,
+  localtime(3),
+  extract ('year' from created_at)
+from users
+`,
+		},
+		{
+			name: "KEYWORD_CASE_PRESERVE_EXCLUDED",
+			sql:  `insert into users (id, name) values ($1, $2) on conflict (id) do update set name = Excluded.name`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCasePreserve(),
+			want: `
+insert into users(
+  id,
+  name
+) values (
+  $1,
+  $2
+)
+on conflict(id)
+do update set
+  name = Excluded.name
+`,
+		},
+		{
+			name: "TYPE_NAME_PG_CATALOG_AS_WRITTEN",
+			sql:  `select q::pg_catalog.int4, q::int, cast(q as pg_catalog.bool), q::"pg_catalog".varchar(3), q::pg_catalog.timestamptz, q::timestamp with time zone, interval '1 day' from t`,
+			want: `
+SELECT
+  q::pg_catalog.int4,
+  q::int,
+  q::pg_catalog.bool,
+  q::pg_catalog.varchar(3),
+  q::pg_catalog.timestamptz,
+  q::timestamp with time zone,
+  '1 day'::interval
+FROM t
+`,
+		},
+		{
+			name: "TYPE_NAME_PG_CATALOG_AS_WRITTEN_CAST_STYLE",
+			sql:  `select q::pg_catalog.int4, q::int from t`,
+			conf: fmtconf.NewDefaultConfig().WithTypeCastStyleCast(),
+			want: `
+SELECT
+  CAST(q AS pg_catalog.int4),
+  CAST(q AS int)
+FROM t
+`,
+		},
+		{
+			name: "KEYWORD_FUNC_NAME",
+			sql:  `select overlay('abc', 'x', 2), substring(name, 1, 2), "position"(name), "extract"(created_at), "trim"(name), "coalesce"(name) from users`,
+			want: `
+SELECT
+  overlay('abc', 'x', 2),
+  substring(name, 1, 2),
+  "position"(name),
+  "extract"(created_at),
+  "trim"(name),
+  "coalesce"(name)
+FROM users
+`,
+		},
+		{
+			name: "NON_ASSOCIATIVE_OPERATORS",
+			sql:  `select (a = b) = c, a = (b = c), (a like b) like c, (a is null) is null, (a < b) is true from t`,
+			want: `
+SELECT
+  (a = b) = c,
+  a = (b = c),
+  (a LIKE b) LIKE c,
+  (a IS NULL) IS NULL,
+  a < b IS TRUE
+FROM t
+`,
+		},
+		{
+			name: "SUBQUERY_QUALIFIED_OPERATOR",
+			sql:  `select a from t where a operator(pg_catalog.=) any(select b from u)`,
+			want: `
+SELECT
+  a
+FROM t
+WHERE a OPERATOR(pg_catalog.=) ANY(
+  SELECT
+    b
+  FROM u
+)
+`,
+		},
+		{
+			name: "INSERT_ON_CONFLICT_INDEX_EXPRESSION",
+			sql:  `insert into users (email) values ($1) on conflict (lower(email)) do nothing`,
+			want: `
+INSERT INTO users(
+  email
+) VALUES (
+  $1
+)
+ON CONFLICT(lower(email))
+DO NOTHING
+`,
+		},
+		{
+			name: "INSERT_ON_CONFLICT_INDEX_PREDICATE",
+			sql:  `insert into users (email) values ($1) on conflict (email collate "C" text_pattern_ops) where deleted_at is null do nothing`,
+			want: `
+INSERT INTO users(
+  email
+) VALUES (
+  $1
+)
+ON CONFLICT(email COLLATE "C" text_pattern_ops) WHERE deleted_at IS NULL
+DO NOTHING
+`,
+		},
+		{
+			name: "KEYWORD_CASE_PRESERVE_ADDED_KEYWORDS",
+			sql:  `select u.user_uuid from users u join orders o on u.user_uuid = o.user_uuid`,
+			conf: fmtconf.NewDefaultConfig().WithKeywordCasePreserve(),
+			want: `
+select
+  u.user_uuid
+from users u
+  inner join orders o
+    on u.user_uuid = o.user_uuid
+`,
+		},
+		{
+			name: "NATIONAL_CHARACTER_LITERAL",
+			sql:  `select n'x', a::char(3) from t`,
+			want: `
+SELECT
+  'x'::pg_catalog.bpchar,
+  a::char(3)
+FROM t
+`,
+		},
+		{
+			name: "COMMENT_OF_MOVED_CLAUSE",
+			sql: `select a from t offset 5 -- skip
+limit 10`,
+			want: `
+SELECT
+  a
+FROM t
+LIMIT 10
+OFFSET 5 -- skip
+`,
+		},
+		{
+			name: "COMMENT_INLINE_BLOCK",
+			sql:  `select a from t where x = 1 and /* mid */ y = 2;`,
+			want: `
+SELECT
+  a
+FROM t
+WHERE x = 1
+  AND /* mid */ y = 2;
 `,
 		},
 	}
@@ -0,0 +1,4 @@
+package internal
+
+// PlaceholderMark is the prefix of the sentinels that replace the placeholders before parsing, ex) :user_uuid -> ttph0_
+const PlaceholderMark = "ttph"
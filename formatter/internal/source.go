@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+type sourceKey struct{}
+
+// source is the sql passed to the parser, the locations of the nodes are the byte offsets in it
+type source struct {
+	sql string
+	// literalEnds maps the start of a literal token to its end
+	literalEnds map[int]int
+}
+
+// WithSource returns a context that holds the sql passed to the parser
+func WithSource(ctx context.Context, sql string) (context.Context, error) {
+	res, err := pg_query.Scan(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	src := source{sql: sql, literalEnds: map[int]int{}}
+	for _, t := range res.Tokens {
+		switch t.Token {
+		case pg_query.Token_SCONST, pg_query.Token_USCONST, pg_query.Token_BCONST, pg_query.Token_XCONST,
+			pg_query.Token_ICONST, pg_query.Token_FCONST, pg_query.Token_TRUE_P, pg_query.Token_FALSE_P:
+			src.literalEnds[int(t.Start)] = int(t.End)
+		}
+	}
+	return context.WithValue(ctx, sourceKey{}, src), nil
+}
+
+// LiteralAt returns the literal written at location of the source, ex)
+//
+//	'O''Reilly', E'\n', $$text$$, X'1F'
+func LiteralAt(ctx context.Context, location int32) (string, bool) {
+	src, ok := ctx.Value(sourceKey{}).(source)
+	if !ok || location < 0 {
+		return "", false
+	}
+	end, ok := src.literalEnds[int(location)]
+	if !ok {
+		return "", false
+	}
+	return src.sql[location:end], true
+}
+
+// SourceFrom returns the source from location to the end, ex) pg_catalog.int4) FROM users
+func SourceFrom(ctx context.Context, location int32) (string, bool) {
+	src, ok := ctx.Value(sourceKey{}).(source)
+	if !ok || location < 0 || int(location) > len(src.sql) {
+		return "", false
+	}
+	return src.sql[location:], true
+}
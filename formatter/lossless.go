@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"fmt"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// LosslessError is returned by Format when the formatted SQL does not keep the meaning of the input SQL
+type LosslessError struct {
+	// Path is the first node path that differs, ex) stmts[0].stmt.select_stmt.where_clause
+	Path string
+	// ParseErr is set when the formatted SQL can not be parsed
+	ParseErr error
+	// Formatted is the SQL that was rejected
+	Formatted string
+}
+
+func (e *LosslessError) Error() string {
+	if e.ParseErr != nil {
+		return fmt.Sprintf("formatted sql can not be parsed: %s", e.ParseErr.Error())
+	}
+	return fmt.Sprintf("formatted sql changes the meaning of the input at %s", e.Path)
+}
+
+// ignoredFields are fields that only hold a position in the source text
+var ignoredFields = map[protoreflect.Name]bool{
+	"location":      true,
+	"name_location": true,
+	"stmt_location": true,
+	"stmt_len":      true,
+}
+
+// verifyLossless reparses formatted and compares its tree with the tree of the input
+func verifyLossless(input *pg_query.ParseResult, formatted string) error {
+	output, err := pg_query.Parse(formatted)
+	if err != nil {
+		return &LosslessError{ParseErr: err, Formatted: formatted}
+	}
+
+	if path := diffMessage(input.ProtoReflect(), output.ProtoReflect(), ""); path != "" {
+		return &LosslessError{Path: path, Formatted: formatted}
+	}
+	return nil
+}
+
+// diffMessage returns the path of the first field that differs between a and b, or "" if they are equal
+func diffMessage(a, b protoreflect.Message, path string) string {
+	// a Node holds one of the node types, report the node itself when the types differ
+	oneofs := a.Descriptor().Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		if a.WhichOneof(oneofs.Get(i)) != b.WhichOneof(oneofs.Get(i)) {
+			return path
+		}
+	}
+
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if ignoredFields[fd.Name()] {
+			continue
+		}
+
+		fieldPath := string(fd.Name())
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		if a.Has(fd) != b.Has(fd) {
+			return fieldPath
+		}
+		if !a.Has(fd) {
+			continue
+		}
+
+		va, vb := a.Get(fd), b.Get(fd)
+		switch {
+		case fd.IsList():
+			la, lb := va.List(), vb.List()
+			for j := 0; j < la.Len() && j < lb.Len(); j++ {
+				itemPath := fmt.Sprintf("%s[%d]", fieldPath, j)
+				if fd.Message() != nil {
+					if d := diffMessage(la.Get(j).Message(), lb.Get(j).Message(), itemPath); d != "" {
+						return d
+					}
+				} else if !la.Get(j).Equal(lb.Get(j)) {
+					return itemPath
+				}
+			}
+			if la.Len() != lb.Len() {
+				return fmt.Sprintf("%s[%d]", fieldPath, min(la.Len(), lb.Len()))
+			}
+		case fd.IsMap():
+			if !va.Equal(vb) {
+				return fieldPath
+			}
+		case fd.Message() != nil:
+			if d := diffMessage(va.Message(), vb.Message(), fieldPath); d != "" {
+				return d
+			}
+		default:
+			if !va.Equal(vb) {
+				return fieldPath
+			}
+		}
+	}
+	return ""
+}
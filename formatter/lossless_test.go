@@ -0,0 +1,90 @@
+package formatter
+
+import (
+	"errors"
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyLossless(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sql       string
+		formatted string
+		wantPath  string
+		wantParse bool
+	}{
+		{
+			name:      "same meaning",
+			sql:       "select u.user_name as un from users as u where u.user_uuid = $1",
+			formatted: "SELECT\n  u.user_name AS un\nFROM users u\nWHERE u.user_uuid = $1",
+		},
+		{
+			name:      "not equal operator",
+			sql:       "select user_name from users where name <> 'taro'",
+			formatted: "SELECT user_name FROM users WHERE name != 'taro'",
+		},
+		{
+			name:      "pg_catalog type",
+			sql:       "select * from access_logs where accessed_at >= now() - interval '3 months'",
+			formatted: "SELECT * FROM access_logs WHERE accessed_at >= now() - '3 months'::interval",
+		},
+		{
+			name:      "dropped function name",
+			sql:       "select max(x) from t",
+			formatted: "SELECT (x) FROM t",
+			wantPath:  "stmts[0].stmt.select_stmt.target_list[0].res_target.val",
+		},
+		{
+			name:      "dropped offset",
+			sql:       "select id from users offset 10",
+			formatted: "SELECT id FROM users",
+			wantPath:  "stmts[0].stmt.select_stmt.limit_offset",
+		},
+		{
+			name:      "dropped target",
+			sql:       "select id, name from users",
+			formatted: "SELECT id FROM users",
+			wantPath:  "stmts[0].stmt.select_stmt.target_list[1]",
+		},
+		{
+			name:      "changed constant",
+			sql:       "select 'O''Reilly'",
+			formatted: "SELECT 'O'",
+			wantPath:  "stmts[0].stmt.select_stmt.target_list[0].res_target.val.a_const.sval.sval",
+		},
+		{
+			name:      "syntax error",
+			sql:       "update public.users u set a = 1 where u.id = 1",
+			formatted: "UPDATE SET a = 1 WHERE u.id = 1",
+			wantParse: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			input, err := pg_query.Parse(tt.sql)
+			assert.NoError(t, err)
+
+			err = verifyLossless(input, tt.formatted)
+			if tt.wantPath == "" && !tt.wantParse {
+				assert.NoError(t, err)
+				return
+			}
+
+			var lerr *LosslessError
+			if assert.True(t, errors.As(err, &lerr)) {
+				assert.Equal(t, tt.wantPath, lerr.Path)
+				assert.Equal(t, tt.wantParse, lerr.ParseErr != nil)
+				assert.Equal(t, tt.formatted, lerr.Formatted)
+			}
+		})
+	}
+}
@@ -3,22 +3,38 @@ package nodeformatter
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
+// FormatAConst outputs a literal as written in the source, it is escaped if the source is not available, ex)
+//
+//	'O''Reilly', E'line\n', $$text$$, B'0101', 0x1F, TRUE
 func FormatAConst(ctx context.Context, ac *pg_query.Node_AConst) (string, error) {
+	if ac.AConst.Val != nil {
+		if literal, ok := internal.LiteralAt(ctx, ac.AConst.Location); ok {
+			return literal, nil
+		}
+	}
+
 	switch val := ac.AConst.Val.(type) {
 	case *pg_query.A_Const_Ival:
 		return fmt.Sprint(val.Ival.Ival), nil
 	case *pg_query.A_Const_Sval:
-		return "'" + val.Sval.Sval + "'", nil
+		return "'" + strings.ReplaceAll(val.Sval.Sval, "'", "''") + "'", nil
 	case *pg_query.A_Const_Boolval:
 		return fmt.Sprint(val.Boolval.Boolval), nil
 	case *pg_query.A_Const_Fval:
 		return val.Fval.Fval, nil
 	case *pg_query.A_Const_Bsval:
-		return "'" + string(val.Bsval.Bsval) + "'", nil
+		// the first character is the type of the bit string, ex) b0101, x1F
+		bs := val.Bsval.Bsval
+		if bs == "" {
+			return "B''", nil
+		}
+		return strings.ToUpper(bs[:1]) + "'" + bs[1:] + "'", nil
 	case nil:
 		return "NULL", nil
 	}
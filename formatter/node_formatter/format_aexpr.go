@@ -2,180 +2,241 @@ package nodeformatter
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/enumconv"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-// ex) user_uuid = $1
-func FormatAExpr(ctx context.Context, aeXpr *pg_query.Node_AExpr, conf *fmtconf.Config) (string, error) {
-	var bu strings.Builder
+// ex) user_uuid = $1, login_count + 1, -amount, user_uuid = ANY($1), status IN ('active', 'pending'),
+// created_at BETWEEN $1 AND $2, name LIKE 'a%', deleted_at IS DISTINCT FROM $1, NULLIF(name, $1)
+func FormatAExpr(ctx context.Context, aeXpr *pg_query.Node_AExpr, indent int, conf *fmtconf.Config) (string, error) {
+	e := aeXpr.AExpr
 
-	// output column name or function call
-	switch lexprNode := aeXpr.AExpr.Lexpr.Node.(type) {
-	case *pg_query.Node_ColumnRef:
-		field, err := FormatColumnRefFields(ctx, lexprNode)
-		if err != nil {
-			return "", err
+	switch e.Kind {
+	case pg_query.A_Expr_Kind_AEXPR_OP:
+		if e.Lexpr == nil {
+			return formatPrefixOp(ctx, e, indent, conf)
 		}
-		bu.WriteString(field)
-	case *pg_query.Node_FuncCall:
-		funcCall, err := FormatFuncname(ctx, lexprNode, conf)
+		return formatBinaryOp(ctx, e, formatOperator(e.Name), indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_OP_ANY, pg_query.A_Expr_Kind_AEXPR_OP_ALL:
+		lexpr, err := formatOperand(ctx, e.Lexpr, exprPrecedence(&pg_query.Node{Node: aeXpr}), false, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(funcCall)
-		bu.WriteString("(")
-
-		arg, err := FormatFuncCallArgs(ctx, lexprNode, 0, conf)
+		rexpr, err := FormatExpr(ctx, e.Rexpr, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(arg)
-		bu.WriteString(")")
-	case *pg_query.Node_CoalesceExpr:
-		bu.WriteString("COALESCE")
-		bu.WriteString("(")
-
-		for argI, arg := range lexprNode.CoalesceExpr.Args {
-			if argI != 0 {
-				bu.WriteString(",")
-				bu.WriteString(" ")
-			}
-			switch n := arg.Node.(type) {
-			case *pg_query.Node_ColumnRef:
-				field, err := FormatColumnRefFields(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(field)
-			case *pg_query.Node_AConst:
-				aconst, err := FormatAConst(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(aconst)
-			case *pg_query.Node_ParamRef:
-				bu.WriteString("$")
-				bu.WriteString(fmt.Sprint(n.ParamRef.Number))
-			}
+		kind := "ANY"
+		if e.Kind == pg_query.A_Expr_Kind_AEXPR_OP_ALL {
+			kind = "ALL"
 		}
-
-		bu.WriteString(")")
+		return lexpr + " " + formatOperator(e.Name) + " " + kind + "(" + rexpr + ")", nil
+	case pg_query.A_Expr_Kind_AEXPR_DISTINCT:
+		return formatBinaryOp(ctx, e, "IS DISTINCT FROM", indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_NOT_DISTINCT:
+		return formatBinaryOp(ctx, e, "IS NOT DISTINCT FROM", indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_NULLIF:
+		return formatExprList(ctx, "NULLIF", []*pg_query.Node{e.Lexpr, e.Rexpr}, indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_IN:
+		return formatInExpr(ctx, e, indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_LIKE, pg_query.A_Expr_Kind_AEXPR_ILIKE, pg_query.A_Expr_Kind_AEXPR_SIMILAR:
+		return formatPatternMatch(ctx, e, indent, conf)
+	case pg_query.A_Expr_Kind_AEXPR_BETWEEN, pg_query.A_Expr_Kind_AEXPR_NOT_BETWEEN,
+		pg_query.A_Expr_Kind_AEXPR_BETWEEN_SYM, pg_query.A_Expr_Kind_AEXPR_NOT_BETWEEN_SYM:
+		return formatBetween(ctx, e, indent, conf)
 	}
 
-	// output operator
-	for _, n := range aeXpr.AExpr.Name {
-		if s, ok := n.Node.(*pg_query.Node_String_); ok {
-			bu.WriteString(" ")
-			if s.String_.Sval == "<>" {
-				bu.WriteString("!=")
-			} else {
-				bu.WriteString(s.String_.Sval)
-			}
+	return DeparseNode(ctx, &pg_query.Node{Node: aeXpr})
+}
+
+// formatOperator outputs the operator, schema qualified operators are written with OPERATOR()
+// ex) !=, OPERATOR(pg_catalog.+)
+func formatOperator(names []*pg_query.Node) string {
+	if op := operatorName(names); op != "" {
+		if op == "<>" {
+			return "!="
 		}
+		return op
 	}
 
-	switch rexprNode := aeXpr.AExpr.Rexpr.Node.(type) {
-	case *pg_query.Node_AConst:
-		res, err := FormatAConst(ctx, rexprNode)
-		if err != nil {
-			return "", err
+	var parts []string
+	for _, name := range names {
+		if s, ok := name.Node.(*pg_query.Node_String_); ok {
+			parts = append(parts, s.String_.Sval)
 		}
-		bu.WriteString(" ")
-		bu.WriteString(res)
+	}
+	return "OPERATOR(" + strings.Join(parts, ".") + ")"
+}
 
-	// output parameter (if named parameter)
-	case *pg_query.Node_ColumnRef:
-		field, err := FormatColumnRefFields(ctx, rexprNode)
-		if err != nil {
-			return "", err
-		}
-		bu.WriteString(" ")
-		bu.WriteString(field)
-
-	// output parameter (if $1)
-	case *pg_query.Node_ParamRef:
-		// Handle ANY operator specially
-		if aeXpr.AExpr.Kind == pg_query.A_Expr_Kind_AEXPR_OP_ANY {
-			bu.WriteString(" ANY($")
-			bu.WriteString(fmt.Sprint(rexprNode.ParamRef.Number))
-			bu.WriteString(")")
-		} else {
-			bu.WriteString(" $")
-			bu.WriteString(fmt.Sprint(rexprNode.ParamRef.Number))
-		}
+// ex) user_uuid = $1, deleted_at IS DISTINCT FROM $1
+func formatBinaryOp(ctx context.Context, e *pg_query.A_Expr, op string, indent int, conf *fmtconf.Config) (string, error) {
+	prec := exprPrecedence(&pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}})
 
-	case *pg_query.Node_FuncCall:
-		funcCall, err := FormatFuncname(ctx, rexprNode, conf)
-		if err != nil {
-			return "", err
-		}
-		bu.WriteString(" ")
-		bu.WriteString(funcCall)
-		bu.WriteString("(")
+	lexpr, err := formatOperand(ctx, e.Lexpr, prec, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	rexpr, err := formatOperand(ctx, e.Rexpr, prec, true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return lexpr + " " + op + " " + rexpr, nil
+}
 
-		arg, err := FormatFuncCallArgs(ctx, rexprNode, 0, conf)
-		if err != nil {
-			return "", err
-		}
-		bu.WriteString(arg)
-		bu.WriteString(")")
+// ex) -amount, -f::int, ~flags
+func formatPrefixOp(ctx context.Context, e *pg_query.A_Expr, indent int, conf *fmtconf.Config) (string, error) {
+	op := formatOperator(e.Name)
+	rexpr, err := formatOperand(ctx, e.Rexpr, exprPrecedence(&pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}}), true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	// - -1 must not be a comment, OPERATOR(pg_catalog.-) needs a space
+	if strings.HasPrefix(op, "OPERATOR(") || strings.ContainsAny(rexpr[:1], "+-*/<>=~!@#%^&|`?") {
+		return op + " " + rexpr, nil
+	}
+	return op + rexpr, nil
+}
 
-	case *pg_query.Node_TypeCast:
-		formatted, err := FormatTypeCast(ctx, rexprNode)
-		if err != nil {
-			return "", err
-		}
-		if rexprNode.TypeCast.TypeName != nil && len(rexprNode.TypeCast.TypeName.ArrayBounds) > 0 {
-			bu.WriteString(" ANY(")
-			bu.WriteString(formatted)
-			bu.WriteString(")")
-		} else {
-			bu.WriteString(" ")
-			bu.WriteString(formatted)
+// ex) status IN ('active', 'pending'), status NOT IN ($1, $2)
+func formatInExpr(ctx context.Context, e *pg_query.A_Expr, indent int, conf *fmtconf.Config) (string, error) {
+	list, ok := e.Rexpr.Node.(*pg_query.Node_List)
+	if !ok {
+		return DeparseNode(ctx, &pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}})
+	}
+
+	lexpr, err := formatOperand(ctx, e.Lexpr, precIn, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	keyword := "IN"
+	if operatorName(e.Name) == "<>" {
+		keyword = "NOT IN"
+	}
+	items, err := formatExprList(ctx, "", list.List.Items, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return lexpr + " " + keyword + " " + items, nil
+}
+
+// ex) name LIKE 'a%', name NOT ILIKE $1, name SIMILAR TO '%(b|d)%' ESCAPE '!'
+func formatPatternMatch(ctx context.Context, e *pg_query.A_Expr, indent int, conf *fmtconf.Config) (string, error) {
+	var keyword string
+	switch operatorName(e.Name) {
+	case "~~":
+		keyword = "LIKE"
+	case "!~~":
+		keyword = "NOT LIKE"
+	case "~~*":
+		keyword = "ILIKE"
+	case "!~~*":
+		keyword = "NOT ILIKE"
+	case "~":
+		keyword = "SIMILAR TO"
+	case "!~":
+		keyword = "NOT SIMILAR TO"
+	default:
+		return DeparseNode(ctx, &pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}})
+	}
+
+	lexpr, err := formatOperand(ctx, e.Lexpr, precIn, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+
+	// the pattern with ESCAPE is the argument of the escape function, SIMILAR TO always calls it
+	pattern, escape := e.Rexpr, (*pg_query.Node)(nil)
+	if fc, ok := e.Rexpr.Node.(*pg_query.Node_FuncCall); ok && isPatternEscapeFunc(fc.FuncCall, e.Kind) {
+		pattern = fc.FuncCall.Args[0]
+		if len(fc.FuncCall.Args) == 2 {
+			escape = fc.FuncCall.Args[1]
 		}
+	} else if e.Kind == pg_query.A_Expr_Kind_AEXPR_SIMILAR {
+		return DeparseNode(ctx, &pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}})
+	}
 
-	case *pg_query.Node_AExpr:
-		inner, err := FormatAExpr(ctx, rexprNode, conf)
+	rexpr, err := formatOperand(ctx, pattern, precIn, true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	res := lexpr + " " + keyword + " " + rexpr
+	if escape != nil {
+		esc, err := formatOperand(ctx, escape, precIn, true, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(" ")
-		bu.WriteString(inner)
-
-	case *pg_query.Node_CoalesceExpr:
-		bu.WriteString(" COALESCE")
-		bu.WriteString("(")
-
-		for argI, arg := range rexprNode.CoalesceExpr.Args {
-			if argI != 0 {
-				bu.WriteString(",")
-				bu.WriteString(" ")
-			}
-			switch n := arg.Node.(type) {
-			case *pg_query.Node_ColumnRef:
-				field, err := FormatColumnRefFields(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(field)
-			case *pg_query.Node_AConst:
-				aconst, err := FormatAConst(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(aconst)
-			case *pg_query.Node_ParamRef:
-				bu.WriteString("$")
-				bu.WriteString(fmt.Sprint(n.ParamRef.Number))
-			}
-		}
+		res += " ESCAPE " + esc
+	}
+	return res, nil
+}
 
-		bu.WriteString(")")
+// isPatternEscapeFunc reports whether the function is the one the parser calls for ESCAPE
+// ex) pg_catalog.like_escape(pattern, '!'), pg_catalog.similar_to_escape(pattern)
+func isPatternEscapeFunc(fc *pg_query.FuncCall, kind pg_query.A_Expr_Kind) bool {
+	if len(fc.Funcname) != 2 || fc.AggOrder != nil || fc.AggFilter != nil || fc.Over != nil || fc.AggStar || fc.AggDistinct || fc.FuncVariadic {
+		return false
+	}
+	schema, ok := fc.Funcname[0].Node.(*pg_query.Node_String_)
+	if !ok || schema.String_.Sval != "pg_catalog" {
+		return false
+	}
+	name, ok := fc.Funcname[1].Node.(*pg_query.Node_String_)
+	if !ok {
+		return false
+	}
+	if kind == pg_query.A_Expr_Kind_AEXPR_SIMILAR {
+		return name.String_.Sval == "similar_to_escape" && (len(fc.Args) == 1 || len(fc.Args) == 2)
+	}
+	return name.String_.Sval == "like_escape" && len(fc.Args) == 2
+}
+
+// ex) created_at BETWEEN $1 AND $2, score NOT BETWEEN SYMMETRIC 1 AND 10
+func formatBetween(ctx context.Context, e *pg_query.A_Expr, indent int, conf *fmtconf.Config) (string, error) {
+	list, ok := e.Rexpr.Node.(*pg_query.Node_List)
+	if !ok || len(list.List.Items) != 2 {
+		return DeparseNode(ctx, &pg_query.Node{Node: &pg_query.Node_AExpr{AExpr: e}})
+	}
+
+	var keyword string
+	switch e.Kind {
+	case pg_query.A_Expr_Kind_AEXPR_BETWEEN:
+		keyword = "BETWEEN"
+	case pg_query.A_Expr_Kind_AEXPR_NOT_BETWEEN:
+		keyword = "NOT BETWEEN"
+	case pg_query.A_Expr_Kind_AEXPR_BETWEEN_SYM:
+		keyword = "BETWEEN SYMMETRIC"
+	case pg_query.A_Expr_Kind_AEXPR_NOT_BETWEEN_SYM:
+		keyword = "NOT BETWEEN SYMMETRIC"
 	}
 
-	return bu.String(), nil
+	lexpr, err := formatOperand(ctx, e.Lexpr, precIn, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	// the bounds are enclosed if they bind looser than BETWEEN, ex) a BETWEEN (b AND c) AND d is not valid
+	low, err := formatOperand(ctx, list.List.Items[0], precIn, true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	high, err := formatOperand(ctx, list.List.Items[1], precIn, true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return lexpr + " " + keyword + " " + low + " AND " + high, nil
+}
+
+// ex) is_active IS NOT TRUE
+func FormatBooleanTest(ctx context.Context, bt *pg_query.Node_BooleanTest, indent int, conf *fmtconf.Config) (string, error) {
+	arg, err := formatOperand(ctx, bt.BooleanTest.Arg, precIs, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	test, err := enumconv.BoolTestTypeToString(bt.BooleanTest.Booltesttype)
+	if err != nil {
+		return "", err
+	}
+	return arg + " " + test, nil
 }
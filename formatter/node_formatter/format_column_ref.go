@@ -4,34 +4,26 @@ import (
 	"context"
 	"strings"
 
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-func FormatColumnRefFields(ctx context.Context, columnRef *pg_query.Node_ColumnRef) (string, error) {
+// ex) u.user_uuid, EXCLUDED.name, "User".*
+func FormatColumnRefFields(ctx context.Context, columnRef *pg_query.Node_ColumnRef, conf *fmtconf.Config) (string, error) {
 	var bu strings.Builder
 
 	for fi, f := range columnRef.ColumnRef.Fields {
+		if fi != 0 {
+			bu.WriteString(".")
+		}
 		switch n := f.Node.(type) {
 		case *pg_query.Node_String_:
-			if fi != 0 {
-				bu.WriteString(".")
-			}
-			switch strings.ToLower(n.String_.Sval) {
-			case "excluded":
+			// the pseudo table of ON CONFLICT DO UPDATE
+			if fi == 0 && len(columnRef.ColumnRef.Fields) > 1 && n.String_.Sval == "excluded" {
 				bu.WriteString("EXCLUDED")
-			case "current_timestamp":
-				bu.WriteString("CURRENT_TIMESTAMP")
-			case "current_date":
-				bu.WriteString("CURRENT_DATE")
-			case "current_time":
-				bu.WriteString("CURRENT_TIME")
-			case "localtime":
-				bu.WriteString("LOCALTIME")
-			case "localtimestamp":
-				bu.WriteString("LOCALTIMESTAMP")
-			default:
-				bu.WriteString(n.String_.Sval)
+				continue
 			}
+			bu.WriteString(FormatIdentifier(n.String_.Sval, conf))
 		case *pg_query.Node_AStar:
 			bu.WriteString("*")
 		}
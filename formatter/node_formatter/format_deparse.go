@@ -0,0 +1,100 @@
+package nodeformatter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+const (
+	deparseExprPrefix     = "SELECT "
+	deparseFromItemPrefix = "SELECT * FROM "
+	deparseSortByPrefix   = "SELECT * ORDER BY "
+	deparseInferPrefix    = "INSERT INTO t DEFAULT VALUES ON CONFLICT "
+	deparseInferSuffix    = " DO NOTHING"
+)
+
+// DeparseNode outputs a node that has no dedicated formatter using the pg_query deparser
+func DeparseNode(ctx context.Context, node *pg_query.Node) (string, error) {
+	if node == nil {
+		return "", nil
+	}
+
+	star := pg_query.MakeResTargetNodeWithVal(pg_query.MakeColumnRefNode([]*pg_query.Node{pg_query.MakeAStarNode()}, 0), 0)
+
+	switch node.Node.(type) {
+	case *pg_query.Node_SelectStmt, *pg_query.Node_InsertStmt, *pg_query.Node_UpdateStmt, *pg_query.Node_DeleteStmt, *pg_query.Node_MergeStmt:
+		return deparse(node, "")
+	case *pg_query.Node_RangeVar, *pg_query.Node_RangeSubselect, *pg_query.Node_RangeFunction, *pg_query.Node_JoinExpr, *pg_query.Node_RangeTableSample, *pg_query.Node_RangeTableFunc:
+		return deparse(makeSelectStmtNode(&pg_query.SelectStmt{
+			TargetList: []*pg_query.Node{star},
+			FromClause: []*pg_query.Node{node},
+		}), deparseFromItemPrefix)
+	case *pg_query.Node_SortBy:
+		return deparse(makeSelectStmtNode(&pg_query.SelectStmt{
+			TargetList: []*pg_query.Node{star},
+			SortClause: []*pg_query.Node{node},
+		}), deparseSortByPrefix)
+	case *pg_query.Node_InferClause:
+		// ex) (lower(email)) WHERE deleted_at IS NULL
+		res, err := deparse(&pg_query.Node{Node: &pg_query.Node_InsertStmt{InsertStmt: &pg_query.InsertStmt{
+			Relation: pg_query.MakeSimpleRangeVar("t", 0),
+			OnConflictClause: &pg_query.OnConflictClause{
+				Action: pg_query.OnConflictAction_ONCONFLICT_NOTHING,
+				Infer:  node.GetInferClause(),
+			},
+			Override: pg_query.OverridingKind_OVERRIDING_NOT_SET,
+		}}}, deparseInferPrefix)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(res, deparseInferSuffix), nil
+	case *pg_query.Node_ResTarget:
+		return deparse(makeSelectStmtNode(&pg_query.SelectStmt{
+			TargetList: []*pg_query.Node{node},
+		}), deparseExprPrefix)
+	}
+
+	return deparse(makeSelectStmtNode(&pg_query.SelectStmt{
+		TargetList: []*pg_query.Node{pg_query.MakeResTargetNodeWithVal(node, 0)},
+	}), deparseExprPrefix)
+}
+
+// DeparseStmt outputs a statement that has no dedicated formatter using the pg_query deparser
+func DeparseStmt(ctx context.Context, stmt *pg_query.Node) (string, error) {
+	return deparse(stmt, "")
+}
+
+func makeSelectStmtNode(stmt *pg_query.SelectStmt) *pg_query.Node {
+	stmt.Op = pg_query.SetOperation_SETOP_NONE
+	stmt.LimitOption = pg_query.LimitOption_LIMIT_OPTION_DEFAULT
+	return &pg_query.Node{Node: &pg_query.Node_SelectStmt{SelectStmt: stmt}}
+}
+
+func deparse(stmt *pg_query.Node, prefix string) (string, error) {
+	res, err := pg_query.Deparse(&pg_query.ParseResult{
+		Stmts: []*pg_query.RawStmt{{Stmt: stmt}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(res, prefix) {
+		return "", fmt.Errorf("DeparseNode: unexpected deparse result: %s", res)
+	}
+	return strings.TrimPrefix(res, prefix), nil
+}
+
+// DeparseOperand outputs a node used as an operand, operator expressions are enclosed in parentheses
+func DeparseOperand(ctx context.Context, node *pg_query.Node) (string, error) {
+	res, err := DeparseNode(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	switch node.Node.(type) {
+	case *pg_query.Node_AExpr, *pg_query.Node_BoolExpr, *pg_query.Node_NullTest, *pg_query.Node_BooleanTest:
+		return "(" + res + ")", nil
+	}
+	return res, nil
+}
@@ -0,0 +1,415 @@
+package nodeformatter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/enumconv"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// FormatSubquery outputs the SELECT statement of a subquery, the first line is indented by indent.
+// The formatter package replaces it with its SELECT formatter, because nodeformatter can not import it.
+var FormatSubquery = func(ctx context.Context, stmt *pg_query.Node_SelectStmt, indent int, conf *fmtconf.Config) (string, error) {
+	res, err := DeparseNode(ctx, &pg_query.Node{Node: stmt})
+	if err != nil {
+		return "", err
+	}
+	return strings.Repeat(internal.GetIndent(conf), indent) + res, nil
+}
+
+// operator precedence, https://www.postgresql.org/docs/current/sql-syntax-lexical.html#SQL-PRECEDENCE
+const (
+	precOr = iota
+	precAnd
+	precNot
+	precIs
+	precComparison
+	precIn
+	precOtherOp
+	precAdd
+	precMul
+	precExp
+	precUnary
+	precTypeCast
+	precPrimary
+)
+
+// FormatExpr outputs an expression, indent is the indent of the line on which the expression starts.
+// Expressions without dedicated formatter are output by the deparser.
+// ex) user_count + 1, COALESCE(u.name, $1), NOW()
+func FormatExpr(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	if node == nil {
+		return "", nil
+	}
+
+	switch n := node.Node.(type) {
+	case *pg_query.Node_ColumnRef:
+		return FormatColumnRefFields(ctx, n, conf)
+	case *pg_query.Node_AConst:
+		return FormatAConst(ctx, n)
+	case *pg_query.Node_ParamRef:
+		return "$" + fmt.Sprint(n.ParamRef.Number), nil
+	case *pg_query.Node_FuncCall:
+		return FormatFuncCall(ctx, n, indent, conf)
+	case *pg_query.Node_AExpr:
+		return FormatAExpr(ctx, n, indent, conf)
+	case *pg_query.Node_TypeCast:
+		return FormatTypeCast(ctx, n, indent, conf)
+	case *pg_query.Node_CaseExpr:
+		return FormatCaseExpr(ctx, n, indent, conf)
+	case *pg_query.Node_NullTest:
+		return FormatNullTest(ctx, n, indent, conf)
+	case *pg_query.Node_BooleanTest:
+		return FormatBooleanTest(ctx, n, indent, conf)
+	case *pg_query.Node_AIndirection:
+		return formatIndirection(ctx, n, indent, conf)
+	case *pg_query.Node_AArrayExpr:
+		// ex) ARRAY[1, 2]
+		res, err := joinExprs(ctx, n.AArrayExpr.Elements, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		return "ARRAY[" + res + "]", nil
+	case *pg_query.Node_RowExpr:
+		// ex) ROW(1, 2), (u.name, u.email)
+		if n.RowExpr.RowFormat == pg_query.CoercionForm_COERCE_EXPLICIT_CALL {
+			return formatExprList(ctx, "ROW", n.RowExpr.Args, indent, conf)
+		}
+		return formatExprList(ctx, "", n.RowExpr.Args, indent, conf)
+	case *pg_query.Node_GroupingSet:
+		// ex) ROLLUP(u.team_id, u.role), CUBE(u.team_id), GROUPING SETS (u.team_id, ())
+		switch n.GroupingSet.Kind {
+		case pg_query.GroupingSetKind_GROUPING_SET_EMPTY:
+			return "()", nil
+		case pg_query.GroupingSetKind_GROUPING_SET_ROLLUP:
+			return formatExprList(ctx, "ROLLUP", n.GroupingSet.Content, indent, conf)
+		case pg_query.GroupingSetKind_GROUPING_SET_CUBE:
+			return formatExprList(ctx, "CUBE", n.GroupingSet.Content, indent, conf)
+		case pg_query.GroupingSetKind_GROUPING_SET_SETS:
+			return formatExprList(ctx, "GROUPING SETS ", n.GroupingSet.Content, indent, conf)
+		}
+	case *pg_query.Node_BoolExpr:
+		return formatBoolExpr(ctx, n, indent, conf)
+	case *pg_query.Node_SubLink:
+		return FormatSubLink(ctx, n, indent, conf)
+	case *pg_query.Node_NamedArgExpr:
+		// ex) make_interval(days => 1)
+		res, err := FormatExpr(ctx, n.NamedArgExpr.Arg, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		return FormatIdentifier(n.NamedArgExpr.Name, conf) + " => " + res, nil
+	case *pg_query.Node_CoalesceExpr:
+		return formatExprList(ctx, "COALESCE", n.CoalesceExpr.Args, indent, conf)
+	case *pg_query.Node_MinMaxExpr:
+		switch n.MinMaxExpr.Op {
+		case pg_query.MinMaxOp_IS_GREATEST:
+			return formatExprList(ctx, "GREATEST", n.MinMaxExpr.Args, indent, conf)
+		case pg_query.MinMaxOp_IS_LEAST:
+			return formatExprList(ctx, "LEAST", n.MinMaxExpr.Args, indent, conf)
+		}
+	case *pg_query.Node_SqlvalueFunction:
+		switch n.SqlvalueFunction.Op {
+		case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIMESTAMP:
+			return "CURRENT_TIMESTAMP", nil
+		case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_DATE:
+			return "CURRENT_DATE", nil
+		case pg_query.SQLValueFunctionOp_SVFOP_CURRENT_TIME:
+			return "CURRENT_TIME", nil
+		case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIME:
+			return "LOCALTIME", nil
+		case pg_query.SQLValueFunctionOp_SVFOP_LOCALTIMESTAMP:
+			return "LOCALTIMESTAMP", nil
+		}
+	}
+
+	return DeparseNode(ctx, node)
+}
+
+// formatOperand outputs an operand of an operator whose precedence is prec, it is enclosed in parentheses if it binds looser.
+// right is true for the right operand, operators of the same precedence are left-associative
+// except for the non-associative ones, ex) (a = b) = c can not be written as a = b = c
+func formatOperand(ctx context.Context, node *pg_query.Node, prec int, right bool, indent int, conf *fmtconf.Config) (string, error) {
+	res, err := FormatExpr(ctx, node, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	p := exprPrecedence(node)
+	if p < prec || (p == prec && p < precPrimary && (right || isNonAssociative(p))) {
+		return "(" + res + ")", nil
+	}
+	return res, nil
+}
+
+// isNonAssociative reports whether the operators of the precedence can not be chained, ex) IS, =, IN, LIKE, BETWEEN
+func isNonAssociative(prec int) bool {
+	return prec == precIs || prec == precComparison || prec == precIn
+}
+
+func exprPrecedence(node *pg_query.Node) int {
+	switch n := node.Node.(type) {
+	case *pg_query.Node_AExpr:
+		switch n.AExpr.Kind {
+		case pg_query.A_Expr_Kind_AEXPR_OP:
+			op := operatorName(n.AExpr.Name)
+			if n.AExpr.Lexpr == nil && (op == "-" || op == "+") {
+				return precUnary
+			}
+			return operatorPrecedence(op)
+		case pg_query.A_Expr_Kind_AEXPR_OP_ANY, pg_query.A_Expr_Kind_AEXPR_OP_ALL:
+			return operatorPrecedence(operatorName(n.AExpr.Name))
+		case pg_query.A_Expr_Kind_AEXPR_DISTINCT, pg_query.A_Expr_Kind_AEXPR_NOT_DISTINCT:
+			return precIs
+		case pg_query.A_Expr_Kind_AEXPR_NULLIF:
+			return precPrimary
+		}
+		return precIn
+	case *pg_query.Node_BoolExpr:
+		switch n.BoolExpr.Boolop {
+		case pg_query.BoolExprType_AND_EXPR:
+			return precAnd
+		case pg_query.BoolExprType_OR_EXPR:
+			return precOr
+		}
+		return precNot
+	case *pg_query.Node_NullTest, *pg_query.Node_BooleanTest:
+		return precIs
+	case *pg_query.Node_SubLink:
+		switch n.SubLink.SubLinkType {
+		case pg_query.SubLinkType_ANY_SUBLINK, pg_query.SubLinkType_ALL_SUBLINK, pg_query.SubLinkType_ROWCOMPARE_SUBLINK:
+			return precComparison
+		}
+	case *pg_query.Node_AConst:
+		// ex) (-1)::int, -1::int is -(1::int)
+		switch v := n.AConst.Val.(type) {
+		case *pg_query.A_Const_Ival:
+			if v.Ival.Ival < 0 {
+				return precUnary
+			}
+		case *pg_query.A_Const_Fval:
+			if strings.HasPrefix(v.Fval.Fval, "-") {
+				return precUnary
+			}
+		}
+	}
+	return precPrimary
+}
+
+func operatorPrecedence(op string) int {
+	switch op {
+	case "^":
+		return precExp
+	case "*", "/", "%":
+		return precMul
+	case "+", "-":
+		return precAdd
+	case "<", ">", "=", "<=", ">=", "<>", "!=":
+		return precComparison
+	}
+	return precOtherOp
+}
+
+// operatorName returns the operator of an expression, schema qualified operators are not supported
+func operatorName(names []*pg_query.Node) string {
+	if len(names) != 1 {
+		return ""
+	}
+	if s, ok := names[0].Node.(*pg_query.Node_String_); ok {
+		return s.String_.Sval
+	}
+	return ""
+}
+
+// formatIndirection outputs the subscripts and the fields of an expression
+// ex) tags[1], (ARRAY[1, 2])[1:2], (get_user($1)).name, (u.address).*
+func formatIndirection(ctx context.Context, n *pg_query.Node_AIndirection, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	arg, err := FormatExpr(ctx, n.AIndirection.Arg, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	// a column followed by a field is a longer column name without parentheses
+	enclose := true
+	if len(n.AIndirection.Indirection) > 0 {
+		if _, ok := n.AIndirection.Indirection[0].Node.(*pg_query.Node_AIndices); ok {
+			switch n.AIndirection.Arg.Node.(type) {
+			case *pg_query.Node_ColumnRef, *pg_query.Node_ParamRef:
+				enclose = false
+			}
+		}
+	}
+	if enclose {
+		arg = "(" + arg + ")"
+	}
+	bu.WriteString(arg)
+
+	for _, ind := range n.AIndirection.Indirection {
+		switch i := ind.Node.(type) {
+		case *pg_query.Node_AIndices:
+			lidx, err := FormatExpr(ctx, i.AIndices.Lidx, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			uidx, err := FormatExpr(ctx, i.AIndices.Uidx, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString("[")
+			if i.AIndices.IsSlice {
+				bu.WriteString(lidx)
+				bu.WriteString(":")
+			}
+			bu.WriteString(uidx)
+			bu.WriteString("]")
+		case *pg_query.Node_String_:
+			bu.WriteString(".")
+			bu.WriteString(FormatIdentifier(i.String_.Sval, conf))
+		case *pg_query.Node_AStar:
+			bu.WriteString(".*")
+		default:
+			return DeparseNode(ctx, &pg_query.Node{Node: n})
+		}
+	}
+
+	return bu.String(), nil
+}
+
+// formatExprList outputs expressions enclosed in parentheses after name
+// ex) COALESCE(u.name, $1)
+func formatExprList(ctx context.Context, name string, args []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	res, err := joinExprs(ctx, args, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return name + "(" + res + ")", nil
+}
+
+// joinExprs outputs expressions separated by commas, ex) u.name, $1
+func joinExprs(ctx context.Context, args []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	for argI, arg := range args {
+		if argI != 0 {
+			bu.WriteString(",")
+			bu.WriteString(" ")
+		}
+		res, err := FormatExpr(ctx, arg, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
+
+	return bu.String(), nil
+}
+
+// formatBoolExpr outputs a condition on one line, nested AND and OR are enclosed in parentheses
+// ex) u.deleted_at IS NULL AND (u.role = 'admin' OR u.role = 'owner')
+func formatBoolExpr(ctx context.Context, be *pg_query.Node_BoolExpr, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
+	if err != nil {
+		return "", err
+	}
+
+	if be.BoolExpr.Boolop == pg_query.BoolExprType_NOT_EXPR {
+		if len(be.BoolExpr.Args) != 1 {
+			return DeparseNode(ctx, &pg_query.Node{Node: be})
+		}
+		// ex) user_uuid NOT IN(...) is NOT over user_uuid IN(...)
+		if sl, ok := be.BoolExpr.Args[0].Node.(*pg_query.Node_SubLink); ok && isInSubLink(sl.SubLink) {
+			return formatSubLink(ctx, sl, true, indent, conf)
+		}
+		res, err := formatOperand(ctx, be.BoolExpr.Args[0], precNot, true, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		return boolStr + " " + res, nil
+	}
+
+	for argI, arg := range be.BoolExpr.Args {
+		if argI != 0 {
+			bu.WriteString(" ")
+			bu.WriteString(boolStr)
+			bu.WriteString(" ")
+		}
+		// nested AND and OR are always enclosed for readability
+		res, err := formatOperand(ctx, arg, precNot, false, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
+
+	return bu.String(), nil
+}
+
+// FormatSubLink outputs a subquery, the closing parenthesis is on the line of indent
+// ex) EXISTS(...), user_uuid IN(...), user_age > ALL(...), (...)
+func FormatSubLink(ctx context.Context, n *pg_query.Node_SubLink, indent int, conf *fmtconf.Config) (string, error) {
+	return formatSubLink(ctx, n, false, indent, conf)
+}
+
+// isInSubLink reports whether the subquery is written with IN, ex) user_uuid IN(...)
+func isInSubLink(sl *pg_query.SubLink) bool {
+	_, ok := sl.Subselect.Node.(*pg_query.Node_SelectStmt)
+	return ok && sl.SubLinkType == pg_query.SubLinkType_ANY_SUBLINK && len(sl.OperName) == 0
+}
+
+// formatSubLink outputs a subquery, not is true for the IN subquery under NOT, ex) user_uuid NOT IN(...)
+func formatSubLink(ctx context.Context, n *pg_query.Node_SubLink, not bool, indent int, conf *fmtconf.Config) (string, error) {
+	selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt)
+	if !ok || n.SubLink.SubLinkType == pg_query.SubLinkType_ROWCOMPARE_SUBLINK || n.SubLink.SubLinkType == pg_query.SubLinkType_MULTIEXPR_SUBLINK {
+		return DeparseNode(ctx, &pg_query.Node{Node: n})
+	}
+
+	var bu strings.Builder
+
+	// output test expression
+	if n.SubLink.Testexpr != nil {
+		res, err := formatOperand(ctx, n.SubLink.Testexpr, precIn, false, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+		bu.WriteString(" ")
+	}
+
+	switch {
+	case isInSubLink(n.SubLink) && not:
+		bu.WriteString("NOT IN")
+	case isInSubLink(n.SubLink):
+		bu.WriteString("IN")
+	case len(n.SubLink.OperName) > 0:
+		// ex) > ALL(, OPERATOR(pg_catalog.=) ANY(
+		bu.WriteString(formatOperator(n.SubLink.OperName))
+		bu.WriteString(" ")
+		fallthrough
+	default:
+		slt, err := enumconv.SubLinkTypeToString(n.SubLink.SubLinkType)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(slt)
+	}
+
+	res, err := FormatSubquery(ctx, selectStmt, indent+1, conf)
+	if err != nil {
+		return "", err
+	}
+	bu.WriteString("(\n")
+	bu.WriteString(res)
+	bu.WriteString("\n")
+	for i := 0; i < indent; i++ {
+		bu.WriteString(internal.GetIndent(conf))
+	}
+	bu.WriteString(")")
+
+	return bu.String(), nil
+}
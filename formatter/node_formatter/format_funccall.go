@@ -2,576 +2,214 @@ package nodeformatter
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strings"
+	"sync"
 
 	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
-	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
+// FormatFuncname outputs the function name with its schema, the names of the built-in functions and
+// the user defined functions of the config are converted by FuncNameTypeCase
+// ex) NOW, myschema.my_func
 func FormatFuncname(ctx context.Context, funcCall *pg_query.Node_FuncCall, conf *fmtconf.Config) (string, error) {
-	var bu strings.Builder
-
+	var names []string
 	for _, name := range funcCall.FuncCall.Funcname {
 		if s, ok := name.Node.(*pg_query.Node_String_); ok {
-			switch s.String_.Sval {
-			case "now":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("now", "NOW", conf))
-			case "count":
-				// https://www.postgresql.org/docs/15/functions-aggregate.html
-				bu.WriteString(convertFuncNameTypeCase("count", "COUNT", conf))
-			case "min":
-				// https://www.postgresql.org/docs/15/functions-aggregate.html
-				bu.WriteString(convertFuncNameTypeCase("min", "MIN", conf))
-			case "gen_random_uuid":
-				// https://www.postgresql.org/docs/15/functions-uuid.html
-				bu.WriteString(convertFuncNameTypeCase("gen_random_uuid", "GEN_RANDOM_UUID", conf))
-			case "current_setting":
-				// https://www.postgresql.org/docs/15/functions-admin.html#FUNCTIONS-ADMIN-SET
-				bu.WriteString(convertFuncNameTypeCase("current_setting", "CURRENT_SETTING", conf))
-			case "set_config":
-				// https://www.postgresql.org/docs/15/functions-admin.html#FUNCTIONS-ADMIN-SET
-				bu.WriteString(convertFuncNameTypeCase("set_config", "SET_CONFIG", conf))
-			case "array_agg":
-				// https://www.postgresql.org/docs/15/functions-aggregate.html
-				bu.WriteString(convertFuncNameTypeCase("array_agg", "ARRAY_AGG", conf))
-			case "json_agg":
-				bu.WriteString(convertFuncNameTypeCase("json_agg", "JSON_AGG", conf))
-			case "json_build_object":
-				bu.WriteString(convertFuncNameTypeCase("json_build_object", "JSON_BUILD_OBJECT", conf))
-			case "array_length":
-				bu.WriteString(convertFuncNameTypeCase("array_length", "ARRAY_LENGTH", conf))
-			case "cardinality":
-				bu.WriteString(convertFuncNameTypeCase("cardinality", "CARDINALITY", conf))
-			case "date":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("date", "DATE", conf))
-			case "current_timestamp":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("current_timestamp", "CURRENT_TIMESTAMP", conf))
-			case "current_date":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("current_date", "CURRENT_DATE", conf))
-			case "current_time":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("current_time", "CURRENT_TIME", conf))
-			case "localtime":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("localtime", "LOCALTIME", conf))
-			case "localtimestamp":
-				// https://www.postgresql.org/docs/15/functions-datetime.html
-				bu.WriteString(convertFuncNameTypeCase("localtimestamp", "LOCALTIMESTAMP", conf))
-			}
+			names = append(names, s.String_.Sval)
 		}
 	}
-
-	return bu.String(), nil
-}
-
-func convertFuncNameTypeCase(lower, upper string, conf *fmtconf.Config) string {
-	switch conf.FuncCallConfig.FuncNameTypeCase {
-	case fmtconf.FUNC_NAME_TYPE_CASE_LOWER:
-		return lower
-	case fmtconf.FUNC_NAME_TYPE_CASE_UPPER:
-		return upper
+	if len(names) == 0 {
+		return "", errors.New("FormatFuncname: function name not found")
 	}
-	return lower
-}
-
-func FormatFuncCallArgs(ctx context.Context, funcCall *pg_query.Node_FuncCall, indent int, conf *fmtconf.Config) (string, error) {
-	var bu strings.Builder
-
-	for argI, arg := range funcCall.FuncCall.Args {
-		if argI != 0 {
-			bu.WriteString(",")
-			bu.WriteString(" ")
-		}
-
-		switch n := arg.Node.(type) {
-		case *pg_query.Node_AConst:
-			res, err := FormatAConst(ctx, n)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		case *pg_query.Node_ParamRef:
-			bu.WriteString("$")
-			bu.WriteString(fmt.Sprint(n.ParamRef.Number))
-		case *pg_query.Node_ColumnRef:
-			if funcCall.FuncCall.AggDistinct {
-				bu.WriteString("DISTINCT ")
-			}
-
-			field, err := FormatColumnRefFields(ctx, n)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(field)
-		case *pg_query.Node_FuncCall:
-			funcName, err := FormatFuncname(ctx, n, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(funcName)
-			bu.WriteString("(")
 
-			arg, err := FormatFuncCallArgs(ctx, n, indent, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(arg)
-
-			bu.WriteString(")")
-		case *pg_query.Node_SubLink:
-			if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-				res, err := FormatSelectStmtForFuncArg(ctx, selectStmt, indent+1, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString("(\n")
-				bu.WriteString(res)
-				bu.WriteString("\n")
-				for i := 0; i < indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				bu.WriteString(")")
-			}
+	schema, name := strings.Join(names[:len(names)-1], "."), names[len(names)-1]
+	// function names are quoted only when needed regardless of QuoteIdentifiers
+	for i, n := range names[:len(names)-1] {
+		if needsQuote(n) {
+			names[i] = quoteIdentifier(n)
 		}
 	}
-
-	if funcCall.FuncCall.AggStar {
-		bu.WriteString("*")
+	switch {
+	case needsFuncNameQuote(schema, name):
+		names[len(names)-1] = quoteIdentifier(name)
+	// quoted names such as "MyFunc" are case sensitive
+	case isBuiltinFunc(schema, name) || conf.IsFuncName(schema, name):
+		names[len(names)-1] = convertFuncNameTypeCase(name, conf)
 	}
 
-	return bu.String(), nil
+	return strings.Join(names, "."), nil
 }
 
-func FormatSelectStmtForFuncArg(ctx context.Context, stmt *pg_query.Node_SelectStmt, indent int, conf *fmtconf.Config) (string, error) {
-	if len(stmt.SelectStmt.TargetList) == 0 {
-		return "", nil
+// needsFuncNameQuote reports whether the function name changes its meaning without quotes.
+// Unlike column names, the keywords such as left and right can be used as function names.
+func needsFuncNameQuote(schema, name string) bool {
+	if !plainIdentifierRegexp.MatchString(name) {
+		return true
 	}
+	switch keywordKind(name) {
+	case pg_query.KeywordKind_RESERVED_KEYWORD, pg_query.KeywordKind_COL_NAME_KEYWORD:
+		// ex) "coalesce"(a) and "position"(a) are user defined functions, overlay(a, b, 2) is a call of the function
+		return schema == "" && !isPlainFuncName(name)
+	}
+	return false
+}
 
-	var bu strings.Builder
-
-	// Handle set operations like UNION ALL first (if this is part of a set operation)
-	if stmt.SelectStmt.Op != pg_query.SetOperation_SETOP_NONE {
-		if stmt.SelectStmt.Larg != nil {
-			leftStmt := &pg_query.Node_SelectStmt{SelectStmt: stmt.SelectStmt.Larg}
-			leftRes, err := FormatSelectStmtForFuncArg(ctx, leftStmt, indent, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(leftRes)
-		}
+// plainFuncNames caches whether the keywords can be written as function names without quotes
+var plainFuncNames sync.Map
 
-		// Add set operation
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		switch stmt.SelectStmt.Op {
-		case pg_query.SetOperation_SETOP_UNION:
-			if stmt.SelectStmt.All {
-				bu.WriteString("UNION ALL")
-			} else {
-				bu.WriteString("UNION")
-			}
-		case pg_query.SetOperation_SETOP_INTERSECT:
-			if stmt.SelectStmt.All {
-				bu.WriteString("INTERSECT ALL")
-			} else {
-				bu.WriteString("INTERSECT")
-			}
-		case pg_query.SetOperation_SETOP_EXCEPT:
-			if stmt.SelectStmt.All {
-				bu.WriteString("EXCEPT ALL")
-			} else {
-				bu.WriteString("EXCEPT")
-			}
-		}
-
-		// Add right side
-		if stmt.SelectStmt.Rarg != nil {
-			rightStmt := &pg_query.Node_SelectStmt{SelectStmt: stmt.SelectStmt.Rarg}
-			rightRes, err := FormatSelectStmtForFuncArg(ctx, rightStmt, indent, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString("\n")
-			bu.WriteString(rightRes)
+// isPlainFuncName reports whether the parser reads name(arg) as a call of the function named name.
+// Most of the reserved and column name keywords are syntax errors or other expressions,
+// only a few such as overlay and substring also have the plain call syntax.
+func isPlainFuncName(name string) bool {
+	if v, ok := plainFuncNames.Load(name); ok {
+		return v.(bool)
+	}
+	plain := false
+	if res, err := pg_query.Parse("SELECT " + name + "(1)"); err == nil && len(res.Stmts) == 1 {
+		if target := res.Stmts[0].Stmt.GetSelectStmt().GetTargetList(); len(target) == 1 {
+			fc := target[0].GetResTarget().GetVal().GetFuncCall()
+			plain = fc != nil && len(fc.Funcname) == 1 && fc.Funcname[0].GetString_().GetSval() == name
 		}
+	}
+	plainFuncNames.Store(name, plain)
+	return plain
+}
 
-		return bu.String(), nil
+// isBuiltinFunc reports whether the function is a function of PostgreSQL, ex) now, pg_catalog.now
+func isBuiltinFunc(schema, name string) bool {
+	if schema != "" && schema != "pg_catalog" {
+		return false
 	}
+	return builtinFuncNames[name]
+}
 
-	// Normal SELECT statement processing
-	for i := 0; i < indent; i++ {
-		bu.WriteString(internal.GetIndent(conf))
+func convertFuncNameTypeCase(name string, conf *fmtconf.Config) string {
+	switch conf.FuncCallConfig.FuncNameTypeCase {
+	case fmtconf.FUNC_NAME_TYPE_CASE_LOWER:
+		return strings.ToLower(name)
+	case fmtconf.FUNC_NAME_TYPE_CASE_UPPER:
+		return strings.ToUpper(name)
 	}
-	bu.WriteString("SELECT")
+	return strings.ToLower(name)
+}
 
-	// output column name
-	for ti, node := range stmt.SelectStmt.TargetList {
-		if ti != 0 {
-			bu.WriteString(",")
-		}
-		if res, ok := node.Node.(*pg_query.Node_ResTarget); ok {
-			bu.WriteString("\n")
-			for i := 0; i < indent+1; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
+// FormatFuncCall outputs a function call, calls of special syntax such as TRIM(BOTH FROM name) are output by the deparser
+// ex) COUNT(DISTINCT user_uuid), ARRAY_AGG(name ORDER BY created_at DESC), COUNT(*) OVER w
+func FormatFuncCall(ctx context.Context, funcCall *pg_query.Node_FuncCall, indent int, conf *fmtconf.Config) (string, error) {
+	if funcCall.FuncCall.Funcformat != pg_query.CoercionForm_COERCE_EXPLICIT_CALL {
+		return DeparseNode(ctx, &pg_query.Node{Node: funcCall})
+	}
 
-			// Handle all target types comprehensively
-			switch n := res.ResTarget.Val.Node.(type) {
-			case *pg_query.Node_ColumnRef:
-				field, err := FormatColumnRefFields(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(field)
-			case *pg_query.Node_FuncCall:
-				funcName, err := FormatFuncname(ctx, n, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(funcName)
-				bu.WriteString("(")
+	var bu strings.Builder
 
-				arg, err := FormatFuncCallArgs(ctx, n, indent+1, conf)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(arg)
-				bu.WriteString(")")
-			case *pg_query.Node_AConst:
-				constValue, err := FormatAConst(ctx, n)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(constValue)
-			case *pg_query.Node_SubLink:
-				// Handle subqueries in target list
-				if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-					subRes, err := FormatSelectStmtForFuncArg(ctx, selectStmt, indent+1, conf)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString("(\n")
-					bu.WriteString(subRes)
-					bu.WriteString("\n")
-					for i := 0; i < indent+1; i++ {
-						bu.WriteString(internal.GetIndent(conf))
-					}
-					bu.WriteString(")")
-				}
-			default:
-				// Handle other expression types that might be in target list
-				bu.WriteString("*") // fallback
-			}
+	funcName, err := FormatFuncname(ctx, funcCall, conf)
+	if err != nil {
+		return "", err
+	}
+	bu.WriteString(funcName)
+	bu.WriteString("(")
 
-			if res.ResTarget.Name != "" {
-				bu.WriteString(" AS ")
-				bu.WriteString(res.ResTarget.Name)
-			}
-		}
+	arg, err := FormatFuncCallArgs(ctx, funcCall, indent, conf)
+	if err != nil {
+		return "", err
 	}
+	bu.WriteString(arg)
+	bu.WriteString(")")
 
-	// output FROM clause
-	for _, node := range stmt.SelectStmt.FromClause {
-		res, err := FormatSelectStmtFromClauseForFuncArg(ctx, node.Node, indent, conf)
+	// ex) PERCENTILE_CONT(0.5) WITHIN GROUP(ORDER BY score)
+	if funcCall.FuncCall.AggWithinGroup {
+		res, err := formatAggOrder(ctx, funcCall, indent, conf)
 		if err != nil {
 			return "", err
 		}
+		bu.WriteString(" WITHIN GROUP(")
 		bu.WriteString(res)
+		bu.WriteString(")")
 	}
 
-	// output WHERE clause - enhanced to handle all WHERE clause types
-	if stmt.SelectStmt.WhereClause != nil {
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("WHERE ")
-
-		whereRes, err := formatWhereClauseNode(ctx, stmt.SelectStmt.WhereClause, conf)
+	// ex) COUNT(*) FILTER(WHERE deleted_at IS NULL)
+	if funcCall.FuncCall.AggFilter != nil {
+		res, err := FormatExpr(ctx, funcCall.FuncCall.AggFilter, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(whereRes)
-	}
-
-	// output GROUP BY clause
-	if len(stmt.SelectStmt.GroupClause) > 0 {
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("GROUP BY ")
-
-		for gi, groupItem := range stmt.SelectStmt.GroupClause {
-			if gi != 0 {
-				bu.WriteString(", ")
-			}
-			if sortBy, ok := groupItem.Node.(*pg_query.Node_SortBy); ok {
-				if colRef, ok := sortBy.SortBy.Node.Node.(*pg_query.Node_ColumnRef); ok {
-					field, err := FormatColumnRefFields(ctx, colRef)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString(field)
-				}
-			}
-		}
-	}
-
-	// output ORDER BY clause
-	if len(stmt.SelectStmt.SortClause) > 0 {
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("ORDER BY ")
-
-		for si, sortItem := range stmt.SelectStmt.SortClause {
-			if si != 0 {
-				bu.WriteString(", ")
-			}
-			if sortBy, ok := sortItem.Node.(*pg_query.Node_SortBy); ok {
-				// Format the sort expression
-				if colRef, ok := sortBy.SortBy.Node.Node.(*pg_query.Node_ColumnRef); ok {
-					field, err := FormatColumnRefFields(ctx, colRef)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString(field)
-				} else if funcCall, ok := sortBy.SortBy.Node.Node.(*pg_query.Node_FuncCall); ok {
-					funcName, err := FormatFuncname(ctx, funcCall, conf)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString(funcName)
-					bu.WriteString("(")
-					arg, err := FormatFuncCallArgs(ctx, funcCall, indent+1, conf)
-					if err != nil {
-						return "", err
-					}
-					bu.WriteString(arg)
-					bu.WriteString(")")
-				}
-
-				// Add sort direction
-				sortDir, err := FormatSortByDir(ctx, sortBy)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(sortDir)
-			}
-		}
+		bu.WriteString(" FILTER(WHERE ")
+		bu.WriteString(res)
+		bu.WriteString(")")
 	}
 
-	// output LIMIT clause
-	if stmt.SelectStmt.LimitCount != nil {
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
-		}
-		bu.WriteString("LIMIT ")
-		if aConst, ok := stmt.SelectStmt.LimitCount.Node.(*pg_query.Node_AConst); ok {
-			limitValue, err := FormatAConst(ctx, aConst)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(limitValue)
+	if funcCall.FuncCall.Over != nil {
+		res, err := FormatOver(ctx, funcCall.FuncCall.Over, indent, conf)
+		if err != nil {
+			return "", err
 		}
+		bu.WriteString(" ")
+		bu.WriteString(res)
 	}
 
 	return bu.String(), nil
 }
 
-// formatWhereClauseNode handles various WHERE clause node types
-func formatWhereClauseNode(ctx context.Context, node *pg_query.Node, conf *fmtconf.Config) (string, error) {
-	switch n := node.Node.(type) {
-	case *pg_query.Node_AExpr:
-		return FormatAExpr(ctx, n, conf)
-	case *pg_query.Node_BoolExpr:
-		return formatBoolExprForFunc(ctx, n, 0, conf)
-	case *pg_query.Node_NullTest:
-		return FormatNullTest(ctx, n)
-	case *pg_query.Node_SubLink:
-		if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-			subRes, err := FormatSelectStmtForFuncArg(ctx, selectStmt, 1, conf)
-			if err != nil {
-				return "", err
-			}
-			return fmt.Sprintf("(%s)", subRes), nil
-		}
-	}
-	return "", fmt.Errorf("unsupported WHERE clause node type")
-}
-
-// formatBoolExprForFunc handles BoolExpr nodes specifically for function arguments
-func formatBoolExprForFunc(ctx context.Context, be *pg_query.Node_BoolExpr, indent int, conf *fmtconf.Config) (string, error) {
+// FormatFuncCallArgs outputs the arguments in the parentheses of a function call
+// ex) DISTINCT user_uuid, user_name, ',' ORDER BY created_at, VARIADIC $1, *
+func FormatFuncCallArgs(ctx context.Context, funcCall *pg_query.Node_FuncCall, indent int, conf *fmtconf.Config) (string, error) {
 	var bu strings.Builder
 
-	for argI, arg := range be.BoolExpr.Args {
-		if argI != 0 {
-			bu.WriteString(" ")
-			switch be.BoolExpr.Boolop {
-			case pg_query.BoolExprType_AND_EXPR:
-				bu.WriteString("AND")
-			case pg_query.BoolExprType_OR_EXPR:
-				bu.WriteString("OR")
-			case pg_query.BoolExprType_NOT_EXPR:
-				bu.WriteString("NOT")
-			}
-			bu.WriteString(" ")
-		}
-
-		switch n := arg.Node.(type) {
-		case *pg_query.Node_AExpr:
-			res, err := FormatAExpr(ctx, n, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		case *pg_query.Node_BoolExpr:
-			res, err := formatBoolExprForFunc(ctx, n, indent+1, conf)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString("(")
-			bu.WriteString(res)
-			bu.WriteString(")")
-		case *pg_query.Node_NullTest:
-			res, err := FormatNullTest(ctx, n)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		}
+	if funcCall.FuncCall.AggDistinct {
+		bu.WriteString("DISTINCT ")
 	}
 
-	return bu.String(), nil
-}
-
-func FormatSelectStmtFromClauseForFuncArg(ctx context.Context, node any, indent int, conf *fmtconf.Config) (string, error) {
-	var bu strings.Builder
-
-	switch n := node.(type) {
-	case *pg_query.Node_RangeVar:
-		tName := ""
-		if n.RangeVar.Schemaname != "" {
-			tName += n.RangeVar.Schemaname
-			tName += "."
-		}
-		tName += n.RangeVar.Relname
-		if n.RangeVar.Alias != nil {
-			tName += " "
-			tName += n.RangeVar.Alias.Aliasname
-		}
-
-		bu.WriteString("\n")
-		for i := 0; i < indent; i++ {
-			bu.WriteString(internal.GetIndent(conf))
+	for argI, arg := range funcCall.FuncCall.Args {
+		if argI != 0 {
+			bu.WriteString(",")
+			bu.WriteString(" ")
 		}
-		bu.WriteString("FROM ")
-		bu.WriteString(tName)
-	case *pg_query.Node_RangeSubselect:
-		// Handle subqueries in FROM clause
-		if selectStmt, ok := n.RangeSubselect.Subquery.Node.(*pg_query.Node_SelectStmt); ok {
-			subRes, err := FormatSelectStmtForFuncArg(ctx, selectStmt, indent+1, conf)
-			if err != nil {
-				return "", err
-			}
-
-			bu.WriteString("\n")
-			for i := 0; i < indent; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
-			bu.WriteString("FROM (")
-			bu.WriteString("\n")
-			bu.WriteString(subRes)
-			bu.WriteString("\n")
-			for i := 0; i < indent; i++ {
-				bu.WriteString(internal.GetIndent(conf))
-			}
-			bu.WriteString(")")
-
-			if n.RangeSubselect.Alias != nil {
-				bu.WriteString(" ")
-				bu.WriteString(n.RangeSubselect.Alias.Aliasname)
-			}
+		// VARIADIC is always the last argument
+		if funcCall.FuncCall.FuncVariadic && argI == len(funcCall.FuncCall.Args)-1 {
+			bu.WriteString("VARIADIC ")
 		}
-	case *pg_query.Node_JoinExpr:
-		// Handle JOIN expressions
-		joinRes, err := formatJoinExprForFunc(ctx, n, indent, conf)
+		res, err := FormatExpr(ctx, arg, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(joinRes)
+		bu.WriteString(res)
 	}
 
-	return bu.String(), nil
-}
-
-// formatJoinExprForFunc handles JOIN expressions for function arguments
-func formatJoinExprForFunc(ctx context.Context, join *pg_query.Node_JoinExpr, indent int, conf *fmtconf.Config) (string, error) {
-	var bu strings.Builder
+	if funcCall.FuncCall.AggStar {
+		bu.WriteString("*")
+	}
 
-	// Left side of join
-	if join.JoinExpr.Larg != nil {
-		leftRes, err := FormatSelectStmtFromClauseForFuncArg(ctx, join.JoinExpr.Larg.Node, indent, conf)
+	if len(funcCall.FuncCall.AggOrder) > 0 && !funcCall.FuncCall.AggWithinGroup {
+		res, err := formatAggOrder(ctx, funcCall, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(leftRes)
+		bu.WriteString(" ")
+		bu.WriteString(res)
 	}
 
-	// Join type and right side
-	bu.WriteString("\n")
-	for i := 0; i < indent+1; i++ {
-		bu.WriteString(internal.GetIndent(conf))
-	}
+	return bu.String(), nil
+}
 
-	switch join.JoinExpr.Jointype {
-	case pg_query.JoinType_JOIN_INNER:
-		bu.WriteString("INNER JOIN")
-	case pg_query.JoinType_JOIN_LEFT:
-		bu.WriteString("LEFT JOIN")
-	case pg_query.JoinType_JOIN_RIGHT:
-		bu.WriteString("RIGHT JOIN")
-	case pg_query.JoinType_JOIN_FULL:
-		bu.WriteString("FULL JOIN")
-	default:
-		bu.WriteString("JOIN")
-	}
+// ex) ORDER BY created_at DESC, user_uuid
+func formatAggOrder(ctx context.Context, funcCall *pg_query.Node_FuncCall, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
 
-	if join.JoinExpr.Rarg != nil {
-		if rangeVar, ok := join.JoinExpr.Rarg.Node.(*pg_query.Node_RangeVar); ok {
+	for sortI, order := range funcCall.FuncCall.AggOrder {
+		if sortI == 0 {
+			bu.WriteString("ORDER BY")
+			bu.WriteString(" ")
+		} else {
+			bu.WriteString(",")
 			bu.WriteString(" ")
-			bu.WriteString(rangeVar.RangeVar.Relname)
-			if rangeVar.RangeVar.Alias != nil {
-				bu.WriteString(" ")
-				bu.WriteString(rangeVar.RangeVar.Alias.Aliasname)
-			}
-		}
-	}
-
-	// Join condition
-	if join.JoinExpr.Quals != nil {
-		bu.WriteString("\n")
-		for i := 0; i < indent+2; i++ {
-			bu.WriteString(internal.GetIndent(conf))
 		}
-		bu.WriteString("ON ")
-
-		qualRes, err := formatWhereClauseNode(ctx, join.JoinExpr.Quals, conf)
+		res, err := FormatSortBy(ctx, order, indent, conf)
 		if err != nil {
 			return "", err
 		}
-		bu.WriteString(qualRes)
+		bu.WriteString(res)
 	}
 
 	return bu.String(), nil
@@ -0,0 +1,70 @@
+package nodeformatter
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+var plainIdentifierRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// keywordKinds caches the keyword kind of the identifiers
+var keywordKinds sync.Map
+
+// FormatIdentifier outputs a table, column or alias name, it is quoted when it can not be written without quotes
+// ex) user_uuid, "User", "order"
+func FormatIdentifier(name string, conf *fmtconf.Config) string {
+	// placeholders are restored after formatting
+	if strings.HasPrefix(name, internal.PlaceholderMark) {
+		return name
+	}
+	if conf.Identifier.Quote != fmtconf.QUOTE_IDENTIFIERS_ALWAYS && !needsQuote(name) {
+		return name
+	}
+	return quoteIdentifier(name)
+}
+
+// ex) "User", "say ""hello"""
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// needsQuote reports whether the identifier changes its meaning without quotes, as quote_identifier of PostgreSQL
+func needsQuote(name string) bool {
+	if !plainIdentifierRegexp.MatchString(name) {
+		return true
+	}
+	// unreserved keywords can be used as identifiers
+	kind := keywordKind(name)
+	return kind != pg_query.KeywordKind_NO_KEYWORD && kind != pg_query.KeywordKind_UNRESERVED_KEYWORD
+}
+
+// keywordKind returns the kind of the keyword in the keyword list of pg_query
+func keywordKind(name string) pg_query.KeywordKind {
+	if kind, ok := keywordKinds.Load(name); ok {
+		return kind.(pg_query.KeywordKind)
+	}
+
+	kind := pg_query.KeywordKind_NO_KEYWORD
+	if res, err := pg_query.Scan(name); err == nil && len(res.Tokens) == 1 {
+		kind = res.Tokens[0].KeywordKind
+	}
+	keywordKinds.Store(name, kind)
+	return kind
+}
+
+// FormatIdentifierList outputs the names of a list of String nodes separated by commas
+// ex) user_uuid, "User"
+func FormatIdentifierList(names []*pg_query.Node, conf *fmtconf.Config) string {
+	var parts []string
+	for _, n := range names {
+		if s, ok := n.Node.(*pg_query.Node_String_); ok {
+			parts = append(parts, FormatIdentifier(s.String_.Sval, conf))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -4,23 +4,21 @@ import (
 	"context"
 	"strings"
 
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
 	"github.com/Toru-Takagi/gopsqlfmt/formatter/enumconv"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-func FormatNullTest(ctx context.Context, nullTest *pg_query.Node_NullTest) (string, error) {
+func FormatNullTest(ctx context.Context, nullTest *pg_query.Node_NullTest, indent int, conf *fmtconf.Config) (string, error) {
 	var bu strings.Builder
 
 	// Format the argument (column or expression)
 	if nullTest.NullTest.Arg != nil {
-		switch argNode := nullTest.NullTest.Arg.Node.(type) {
-		case *pg_query.Node_ColumnRef:
-			field, err := FormatColumnRefFields(ctx, argNode)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(field)
+		res, err := formatOperand(ctx, nullTest.NullTest.Arg, precIs, false, indent, conf)
+		if err != nil {
+			return "", err
 		}
+		bu.WriteString(res)
 	}
 
 	// Format the null test type (IS NULL or IS NOT NULL)
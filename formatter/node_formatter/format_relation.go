@@ -2,14 +2,66 @@ package nodeformatter
 
 import (
 	"context"
+	"strings"
 
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-func FormatRelation(ctx context.Context, relation *pg_query.RangeVar) (string, error) {
+// FormatRelation outputs the target table of INSERT, UPDATE and DELETE with a leading space, ex) " ONLY public.users u"
+func FormatRelation(ctx context.Context, relation *pg_query.RangeVar, conf *fmtconf.Config) (string, error) {
 	if relation == nil {
 		return "", nil
 	}
 
-	return " " + relation.Relname, nil
+	res, err := FormatRangeVar(ctx, relation, conf)
+	if err != nil {
+		return "", err
+	}
+	return " " + res, nil
+}
+
+// FormatRangeVar outputs a table name with its alias, ex) ONLY public.users u
+func FormatRangeVar(ctx context.Context, rangeVar *pg_query.RangeVar, conf *fmtconf.Config) (string, error) {
+	tName := FormatRangeVarName(rangeVar, conf)
+	if rangeVar.Alias != nil {
+		tName += " "
+		tName += FormatAlias(rangeVar.Alias, conf)
+	}
+	return tName, nil
+}
+
+// FormatRangeVarName outputs a table name without its alias, ex) ONLY catalog.public.users
+func FormatRangeVarName(rangeVar *pg_query.RangeVar, conf *fmtconf.Config) string {
+	var bu strings.Builder
+
+	// inheritance is disabled by ONLY
+	if !rangeVar.Inh {
+		bu.WriteString("ONLY ")
+	}
+	if rangeVar.Catalogname != "" {
+		bu.WriteString(FormatIdentifier(rangeVar.Catalogname, conf))
+		bu.WriteString(".")
+	}
+	if rangeVar.Schemaname != "" {
+		bu.WriteString(FormatIdentifier(rangeVar.Schemaname, conf))
+		bu.WriteString(".")
+	}
+	bu.WriteString(FormatIdentifier(rangeVar.Relname, conf))
+
+	return bu.String()
+}
+
+// FormatAlias outputs an alias with its column aliases, ex) u(id, name)
+func FormatAlias(alias *pg_query.Alias, conf *fmtconf.Config) string {
+	var bu strings.Builder
+
+	bu.WriteString(FormatIdentifier(alias.Aliasname, conf))
+	if len(alias.Colnames) > 0 {
+		bu.WriteString("(")
+		bu.WriteString(FormatIdentifierList(alias.Colnames, conf))
+		bu.WriteString(")")
+	}
+
+	return bu.String()
 }
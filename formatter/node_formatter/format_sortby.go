@@ -3,18 +3,58 @@ package nodeformatter
 import (
 	"context"
 	"errors"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
 func FormatSortByDir(ctx context.Context, sortBy *pg_query.Node_SortBy) (string, error) {
+	var dir string
 	switch sortBy.SortBy.SortbyDir {
 	case pg_query.SortByDir_SORTBY_ASC:
-		return " ASC", nil
+		dir = " ASC"
 	case pg_query.SortByDir_SORTBY_DESC:
-		return " DESC", nil
+		dir = " DESC"
 	case pg_query.SortByDir_SORTBY_DEFAULT:
-		return "", nil
+		dir = ""
+	case pg_query.SortByDir_SORTBY_USING:
+		var ops []string
+		for _, op := range sortBy.SortBy.UseOp {
+			if s, ok := op.Node.(*pg_query.Node_String_); ok {
+				ops = append(ops, s.String_.Sval)
+			}
+		}
+		dir = " USING " + strings.Join(ops, ".")
+	default:
+		return "", errors.New("FormatSortByDir not implemented")
+	}
+
+	switch sortBy.SortBy.SortbyNulls {
+	case pg_query.SortByNulls_SORTBY_NULLS_FIRST:
+		dir += " NULLS FIRST"
+	case pg_query.SortByNulls_SORTBY_NULLS_LAST:
+		dir += " NULLS LAST"
+	}
+	return dir, nil
+}
+
+// FormatSortBy outputs a sort key, it is used for ORDER BY of SELECT and aggregate functions
+// ex) created_at DESC NULLS LAST
+func FormatSortBy(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	sortBy, ok := node.Node.(*pg_query.Node_SortBy)
+	if !ok {
+		return DeparseNode(ctx, node)
+	}
+
+	res, err := FormatExpr(ctx, sortBy.SortBy.Node, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	dir, err := FormatSortByDir(ctx, sortBy)
+	if err != nil {
+		return "", err
 	}
-	return "", errors.New("FormatSortByDir not implemented")
+	return res + dir, nil
 }
@@ -2,48 +2,34 @@ package nodeformatter
 
 import (
 	"context"
-	"strings"
 
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
-func FormatTypeCast(ctx context.Context, tc *pg_query.Node_TypeCast) (string, error) {
-	var bu strings.Builder
+// FormatTypeCast outputs a type cast in the style of TypeCastStyle
+// ex) created_at::date, CAST(created_at AS date)
+func FormatTypeCast(ctx context.Context, tc *pg_query.Node_TypeCast, indent int, conf *fmtconf.Config) (string, error) {
+	if tc.TypeCast.Arg == nil || tc.TypeCast.TypeName == nil {
+		return DeparseNode(ctx, &pg_query.Node{Node: tc})
+	}
 
-	if tc.TypeCast.Arg != nil {
-		switch arg := tc.TypeCast.Arg.Node.(type) {
-		case *pg_query.Node_ColumnRef:
-			field, err := FormatColumnRefFields(ctx, arg)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(field)
-		case *pg_query.Node_AConst:
-			res, err := FormatAConst(ctx, arg)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		case *pg_query.Node_TypeCast:
-			res, err := FormatTypeCast(ctx, arg)
-			if err != nil {
-				return "", err
-			}
-			bu.WriteString(res)
-		}
+	typeName, err := FormatTypeName(ctx, tc.TypeCast.TypeName, indent, conf)
+	if err != nil {
+		return "", err
 	}
 
-	if tc.TypeCast.TypeName != nil {
-		bu.WriteString("::")
-		if len(tc.TypeCast.TypeName.Names) > 0 {
-			if name, ok := tc.TypeCast.TypeName.Names[len(tc.TypeCast.TypeName.Names)-1].Node.(*pg_query.Node_String_); ok {
-				bu.WriteString(name.String_.Sval)
-			}
-		}
-		if len(tc.TypeCast.TypeName.ArrayBounds) > 0 {
-			bu.WriteString("[]")
+	if conf.TypeCastStyle == fmtconf.TYPE_CAST_STYLE_CAST {
+		arg, err := FormatExpr(ctx, tc.TypeCast.Arg, indent, conf)
+		if err != nil {
+			return "", err
 		}
+		return "CAST(" + arg + " AS " + typeName + ")", nil
 	}
 
-	return bu.String(), nil
+	arg, err := formatOperand(ctx, tc.TypeCast.Arg, precTypeCast, false, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return arg + "::" + typeName, nil
 }
@@ -0,0 +1,190 @@
+package nodeformatter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// the masks of the interval fields, https://github.com/postgres/postgres/blob/master/src/include/utils/datetime.h
+const (
+	intervalMaskYear   = 1 << 2
+	intervalMaskMonth  = 1 << 1
+	intervalMaskDay    = 1 << 3
+	intervalMaskHour   = 1 << 10
+	intervalMaskMinute = 1 << 11
+	intervalMaskSecond = 1 << 12
+	intervalFullRange  = 0x7FFF
+)
+
+var intervalFields = map[int32]string{
+	intervalMaskYear:                     "year",
+	intervalMaskMonth:                    "month",
+	intervalMaskDay:                      "day",
+	intervalMaskHour:                     "hour",
+	intervalMaskMinute:                   "minute",
+	intervalMaskSecond:                   "second",
+	intervalMaskYear | intervalMaskMonth: "year to month",
+	intervalMaskDay | intervalMaskHour:   "day to hour",
+	intervalMaskDay | intervalMaskHour | intervalMaskMinute:                      "day to minute",
+	intervalMaskDay | intervalMaskHour | intervalMaskMinute | intervalMaskSecond: "day to second",
+	intervalMaskHour | intervalMaskMinute:                                        "hour to minute",
+	intervalMaskHour | intervalMaskMinute | intervalMaskSecond:                   "hour to second",
+	intervalMaskMinute | intervalMaskSecond:                                      "minute to second",
+}
+
+// systemTypeNames are the SQL standard names of the types that the parser qualifies with pg_catalog, ex) int is pg_catalog.int4
+var systemTypeNames = map[string]string{
+	"bool":        "boolean",
+	"int2":        "smallint",
+	"int4":        "int",
+	"int8":        "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"numeric":     "numeric",
+	"bpchar":      "char",
+	"varchar":     "varchar",
+	"bit":         "bit",
+	"varbit":      "bit varying",
+	"time":        "time",
+	"timetz":      "time",
+	"timestamp":   "timestamp",
+	"timestamptz": "timestamp",
+	"interval":    "interval",
+	"json":        "json",
+}
+
+// FormatTypeName outputs a type name with its modifiers and array bounds
+// ex) varchar(255), timestamp(3) with time zone, interval day to second, public."MyType"[], SETOF int
+func FormatTypeName(ctx context.Context, tn *pg_query.TypeName, indent int, conf *fmtconf.Config) (string, error) {
+	var names []string
+	for _, name := range tn.Names {
+		if s, ok := name.Node.(*pg_query.Node_String_); ok {
+			names = append(names, s.String_.Sval)
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("FormatTypeName: type name not found")
+	}
+
+	var bu strings.Builder
+
+	if tn.Setof {
+		bu.WriteString("SETOF ")
+	}
+
+	last := names[len(names)-1]
+	// char is char(1), bpchar without the length is the type of N'x' and is kept as pg_catalog.bpchar
+	isBareBpchar := last == "bpchar" && len(tn.Typmods) == 0
+	if sysName, ok := systemTypeNames[last]; ok && len(names) == 2 && names[0] == "pg_catalog" && !isBareBpchar && !isQualifiedInSource(ctx, tn) {
+		res, err := formatSystemTypeName(ctx, sysName, last, tn.Typmods, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	} else {
+		// type names are quoted only when needed regardless of QuoteIdentifiers
+		for i, name := range names {
+			if i != 0 {
+				bu.WriteString(".")
+			}
+			if (i < len(names)-1 && needsQuote(name)) || (i == len(names)-1 && needsTypeNameQuote(len(names) > 1, name)) {
+				bu.WriteString(quoteIdentifier(name))
+			} else {
+				bu.WriteString(name)
+			}
+		}
+		if tn.PctType {
+			bu.WriteString("%TYPE")
+		}
+		res, err := formatTypmods(ctx, tn.Typmods, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
+
+	// ex) int[][3]
+	for _, bound := range tn.ArrayBounds {
+		if i, ok := bound.Node.(*pg_query.Node_Integer); ok && i.Integer.Ival >= 0 {
+			bu.WriteString(fmt.Sprintf("[%d]", i.Integer.Ival))
+		} else {
+			bu.WriteString("[]")
+		}
+	}
+
+	return bu.String(), nil
+}
+
+// isQualifiedInSource reports whether pg_catalog of the type name is written in the source, ex) q::pg_catalog.int4
+func isQualifiedInSource(ctx context.Context, tn *pg_query.TypeName) bool {
+	src, ok := internal.SourceFrom(ctx, tn.Location)
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(src), "pg_catalog") || strings.HasPrefix(src, `"pg_catalog"`)
+}
+
+// formatSystemTypeName outputs a type of the SQL standard syntax, typmods of interval are the fields and the precision
+// ex) time(3) with time zone, interval hour to minute
+func formatSystemTypeName(ctx context.Context, sysName, name string, typmods []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	if name == "interval" && len(typmods) > 0 {
+		if mask, ok := typmods[0].Node.(*pg_query.Node_AConst); ok {
+			if ival, ok := mask.AConst.Val.(*pg_query.A_Const_Ival); ok {
+				fields, ok := intervalFields[ival.Ival.Ival]
+				if ok || ival.Ival.Ival == intervalFullRange {
+					if fields != "" {
+						sysName += " " + fields
+					}
+					typmods = typmods[1:]
+				}
+			}
+		}
+	}
+
+	res, err := formatTypmods(ctx, typmods, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "timetz", "timestamptz":
+		return sysName + res + " with time zone", nil
+	}
+	return sysName + res, nil
+}
+
+// ex) (10, 2)
+func formatTypmods(ctx context.Context, typmods []*pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	if len(typmods) == 0 {
+		return "", nil
+	}
+	var mods []string
+	for _, mod := range typmods {
+		res, err := FormatExpr(ctx, mod, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		mods = append(mods, res)
+	}
+	return "(" + strings.Join(mods, ", ") + ")", nil
+}
+
+// needsTypeNameQuote reports whether the type name changes its meaning without quotes.
+// The keywords such as int and interval are the types of the SQL standard syntax unless they are qualified.
+func needsTypeNameQuote(qualified bool, name string) bool {
+	if !plainIdentifierRegexp.MatchString(name) {
+		return true
+	}
+	if qualified {
+		return false
+	}
+	switch keywordKind(name) {
+	case pg_query.KeywordKind_RESERVED_KEYWORD, pg_query.KeywordKind_COL_NAME_KEYWORD:
+		return true
+	}
+	return false
+}
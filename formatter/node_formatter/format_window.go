@@ -0,0 +1,171 @@
+package nodeformatter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// frame options of WindowDef, https://github.com/postgres/postgres/blob/master/src/include/nodes/parsenodes.h
+const (
+	frameOptionNonDefault              = 0x00001
+	frameOptionRange                   = 0x00002
+	frameOptionRows                    = 0x00004
+	frameOptionGroups                  = 0x00008
+	frameOptionBetween                 = 0x00010
+	frameOptionStartUnboundedPreceding = 0x00020
+	frameOptionEndUnboundedPreceding   = 0x00040
+	frameOptionStartUnboundedFollowing = 0x00080
+	frameOptionEndUnboundedFollowing   = 0x00100
+	frameOptionStartCurrentRow         = 0x00200
+	frameOptionEndCurrentRow           = 0x00400
+	frameOptionStartOffsetPreceding    = 0x00800
+	frameOptionEndOffsetPreceding      = 0x01000
+	frameOptionStartOffsetFollowing    = 0x02000
+	frameOptionEndOffsetFollowing      = 0x04000
+	frameOptionExcludeCurrentRow       = 0x08000
+	frameOptionExcludeGroup            = 0x10000
+	frameOptionExcludeTies             = 0x20000
+)
+
+// FormatOver outputs the window of a window function
+// ex) OVER(PARTITION BY user_uuid ORDER BY created_at DESC), OVER w
+func FormatOver(ctx context.Context, w *pg_query.WindowDef, indent int, conf *fmtconf.Config) (string, error) {
+	// reference to a window of the WINDOW clause
+	if w.Name != "" {
+		return "OVER " + FormatIdentifier(w.Name, conf), nil
+	}
+
+	res, err := FormatWindowDef(ctx, w, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	return "OVER(" + res + ")", nil
+}
+
+// FormatWindowDef outputs the window definition in the parentheses
+// ex) PARTITION BY user_uuid ORDER BY created_at ROWS BETWEEN 1 PRECEDING AND CURRENT ROW
+func FormatWindowDef(ctx context.Context, w *pg_query.WindowDef, indent int, conf *fmtconf.Config) (string, error) {
+	var parts []string
+
+	// existing window name, ex) OVER(w ORDER BY created_at)
+	if w.Refname != "" {
+		parts = append(parts, FormatIdentifier(w.Refname, conf))
+	}
+
+	if len(w.PartitionClause) > 0 {
+		var bu strings.Builder
+		bu.WriteString("PARTITION BY ")
+		for i, node := range w.PartitionClause {
+			if i != 0 {
+				bu.WriteString(", ")
+			}
+			res, err := FormatExpr(ctx, node, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
+		}
+		parts = append(parts, bu.String())
+	}
+
+	if len(w.OrderClause) > 0 {
+		var bu strings.Builder
+		bu.WriteString("ORDER BY ")
+		for i, node := range w.OrderClause {
+			if i != 0 {
+				bu.WriteString(", ")
+			}
+			res, err := FormatSortBy(ctx, node, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(res)
+		}
+		parts = append(parts, bu.String())
+	}
+
+	if w.FrameOptions&frameOptionNonDefault != 0 {
+		res, err := formatFrameClause(ctx, w, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, res)
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// ex) ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW EXCLUDE TIES
+func formatFrameClause(ctx context.Context, w *pg_query.WindowDef, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	switch {
+	case w.FrameOptions&frameOptionRows != 0:
+		bu.WriteString("ROWS")
+	case w.FrameOptions&frameOptionGroups != 0:
+		bu.WriteString("GROUPS")
+	default:
+		bu.WriteString("RANGE")
+	}
+
+	start, err := formatFrameBound(ctx, w.FrameOptions, w.StartOffset, true, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	if w.FrameOptions&frameOptionBetween != 0 {
+		end, err := formatFrameBound(ctx, w.FrameOptions, w.EndOffset, false, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(" BETWEEN ")
+		bu.WriteString(start)
+		bu.WriteString(" AND ")
+		bu.WriteString(end)
+	} else {
+		bu.WriteString(" ")
+		bu.WriteString(start)
+	}
+
+	switch {
+	case w.FrameOptions&frameOptionExcludeCurrentRow != 0:
+		bu.WriteString(" EXCLUDE CURRENT ROW")
+	case w.FrameOptions&frameOptionExcludeGroup != 0:
+		bu.WriteString(" EXCLUDE GROUP")
+	case w.FrameOptions&frameOptionExcludeTies != 0:
+		bu.WriteString(" EXCLUDE TIES")
+	}
+
+	return bu.String(), nil
+}
+
+// ex) UNBOUNDED PRECEDING, CURRENT ROW, $1 FOLLOWING
+func formatFrameBound(ctx context.Context, options int32, offset *pg_query.Node, start bool, indent int, conf *fmtconf.Config) (string, error) {
+	// each option of the end bound is the next bit of the start bound
+	if !start {
+		options >>= 1
+	}
+
+	switch {
+	case options&frameOptionStartUnboundedPreceding != 0:
+		return "UNBOUNDED PRECEDING", nil
+	case options&frameOptionStartUnboundedFollowing != 0:
+		return "UNBOUNDED FOLLOWING", nil
+	case options&frameOptionStartCurrentRow != 0:
+		return "CURRENT ROW", nil
+	}
+
+	res, err := FormatExpr(ctx, offset, indent, conf)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case options&frameOptionStartOffsetPreceding != 0:
+		return res + " PRECEDING", nil
+	case options&frameOptionStartOffsetFollowing != 0:
+		return res + " FOLLOWING", nil
+	}
+	return res, nil
+}
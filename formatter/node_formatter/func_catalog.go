@@ -0,0 +1,105 @@
+package nodeformatter
+
+// builtinFuncNames are the functions of PostgreSQL whose names are converted by FuncNameTypeCase
+var builtinFuncNames = map[string]bool{}
+
+func init() {
+	for _, names := range [][]string{
+		// https://www.postgresql.org/docs/15/functions-aggregate.html
+		{
+			"any_value", "array_agg", "avg", "bit_and", "bit_or", "bit_xor", "bool_and", "bool_or", "count", "every",
+			"json_agg", "json_object_agg", "jsonb_agg", "jsonb_object_agg", "max", "min", "range_agg", "range_intersect_agg",
+			"string_agg", "sum", "xmlagg", "corr", "covar_pop", "covar_samp", "regr_avgx", "regr_avgy", "regr_count",
+			"regr_intercept", "regr_r2", "regr_slope", "regr_sxx", "regr_sxy", "regr_syy", "stddev", "stddev_pop",
+			"stddev_samp", "variance", "var_pop", "var_samp", "mode", "percentile_cont", "percentile_disc",
+			"grouping",
+		},
+		// https://www.postgresql.org/docs/15/functions-window.html
+		{
+			"row_number", "rank", "dense_rank", "percent_rank", "cume_dist", "ntile", "lag", "lead", "first_value",
+			"last_value", "nth_value",
+		},
+		// https://www.postgresql.org/docs/15/functions-math.html
+		{
+			"abs", "cbrt", "ceil", "ceiling", "degrees", "div", "exp", "factorial", "floor", "gcd", "lcm", "ln", "log",
+			"log10", "min_scale", "mod", "pi", "power", "radians", "round", "scale", "sign", "sqrt", "trim_scale", "trunc",
+			"width_bucket", "random", "setseed", "acos", "asin", "atan", "atan2", "cos", "cot", "sin", "tan",
+		},
+		// https://www.postgresql.org/docs/15/functions-string.html
+		{
+			"ascii", "bit_length", "btrim", "char_length", "character_length", "chr", "concat", "concat_ws", "format",
+			"initcap", "left", "length", "lower", "lpad", "ltrim", "md5", "octet_length", "overlay", "position",
+			"quote_ident", "quote_literal", "quote_nullable", "regexp_count", "regexp_instr", "regexp_like",
+			"regexp_match", "regexp_matches", "regexp_replace", "regexp_split_to_array", "regexp_split_to_table",
+			"regexp_substr", "repeat", "replace", "reverse", "right", "rpad", "rtrim", "split_part", "starts_with",
+			"string_to_array", "string_to_table", "strpos", "substr", "substring", "to_ascii", "to_hex", "translate",
+			"trim", "upper", "encode", "decode", "sha224", "sha256", "sha384", "sha512",
+		},
+		// https://www.postgresql.org/docs/15/functions-formatting.html
+		{
+			"to_char", "to_date", "to_number", "to_timestamp",
+		},
+		// https://www.postgresql.org/docs/15/functions-datetime.html
+		{
+			"age", "clock_timestamp", "current_date", "current_time", "current_timestamp", "date", "date_bin",
+			"date_part", "date_trunc", "extract", "isfinite", "justify_days", "justify_hours", "justify_interval",
+			"localtime", "localtimestamp", "make_date", "make_interval", "make_time", "make_timestamp",
+			"make_timestamptz", "now", "statement_timestamp", "timeofday", "transaction_timestamp", "pg_sleep",
+			"pg_sleep_for", "pg_sleep_until",
+		},
+		// https://www.postgresql.org/docs/15/functions-conditional.html
+		{
+			"coalesce", "nullif", "greatest", "least",
+		},
+		// https://www.postgresql.org/docs/15/functions-array.html
+		{
+			"array_append", "array_cat", "array_dims", "array_fill", "array_length", "array_lower", "array_ndims",
+			"array_position", "array_positions", "array_prepend", "array_remove", "array_replace", "array_to_string",
+			"array_upper", "cardinality", "trim_array", "unnest",
+		},
+		// https://www.postgresql.org/docs/15/functions-json.html
+		{
+			"to_json", "to_jsonb", "array_to_json", "row_to_json", "json_build_array", "jsonb_build_array",
+			"json_build_object", "jsonb_build_object", "json_object", "jsonb_object", "json_array_elements",
+			"jsonb_array_elements", "json_array_elements_text", "jsonb_array_elements_text", "json_array_length",
+			"jsonb_array_length", "json_each", "jsonb_each", "json_each_text", "jsonb_each_text", "json_extract_path",
+			"jsonb_extract_path", "json_extract_path_text", "jsonb_extract_path_text", "json_object_keys",
+			"jsonb_object_keys", "json_populate_record", "jsonb_populate_record", "json_populate_recordset",
+			"jsonb_populate_recordset", "json_to_record", "jsonb_to_record", "json_to_recordset", "jsonb_to_recordset",
+			"json_strip_nulls", "jsonb_strip_nulls", "jsonb_set", "jsonb_set_lax", "jsonb_insert", "jsonb_path_exists",
+			"jsonb_path_match", "jsonb_path_query", "jsonb_path_query_array", "jsonb_path_query_first", "jsonb_pretty",
+			"json_typeof", "jsonb_typeof",
+		},
+		// https://www.postgresql.org/docs/15/functions-srf.html
+		{
+			"generate_series", "generate_subscripts",
+		},
+		// https://www.postgresql.org/docs/15/functions-uuid.html
+		{
+			"gen_random_uuid",
+		},
+		// https://www.postgresql.org/docs/15/functions-sequence.html
+		{
+			"nextval", "currval", "setval", "lastval",
+		},
+		// https://www.postgresql.org/docs/15/functions-admin.html
+		{
+			"current_setting", "set_config", "pg_advisory_lock", "pg_advisory_unlock", "pg_advisory_xact_lock",
+			"pg_try_advisory_lock", "pg_try_advisory_xact_lock", "pg_cancel_backend", "pg_terminate_backend",
+		},
+		// https://www.postgresql.org/docs/15/functions-info.html
+		{
+			"current_database", "current_schema", "current_schemas", "pg_backend_pid", "version", "txid_current",
+			"pg_current_xact_id",
+		},
+		// https://www.postgresql.org/docs/15/functions-textsearch.html
+		{
+			"to_tsvector", "to_tsquery", "plainto_tsquery", "phraseto_tsquery", "websearch_to_tsquery", "ts_rank",
+			"ts_rank_cd", "ts_headline", "setweight",
+		},
+	} {
+		for _, name := range names {
+			builtinFuncNames[name] = true
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/pganalyze/pg_query_go/v6/parser"
+)
+
+var (
+	templateActionRegexp = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	// ex) %s, %d, %[1]v, %-10s, %%
+	fmtVerbRegexp  = regexp.MustCompile(`%%|%(\[\d+\])?[-+#0]*(\d+|\*)?(\.(\d+|\*)?)?(\[\d+\])?[bcdeEfFgGoOpqstTUvxX]`)
+	sentinelRegexp = regexp.MustCompile(internal.PlaceholderMark + `(\d+)_`)
+)
+
+// PlaceholderError is returned by Format when a placeholder is at a position where no expression can be written
+type PlaceholderError struct {
+	// Placeholder is written in the input sql, ex) %s, {{ .Table }}
+	Placeholder string
+	// Line is the line of the placeholder, starting from 1
+	Line int
+	// Message is the error of the parser
+	Message string
+}
+
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("placeholder %s at line %d can not be replaced with an expression: %s", e.Placeholder, e.Line, e.Message)
+}
+
+// placeholders replaces the placeholders of the dialects with sentinels that the parser accepts as column names,
+// and restores them as written after formatting
+type placeholders struct {
+	originals []string
+}
+
+// ex) ttph0_
+func (p *placeholders) sentinel(original string) string {
+	s := fmt.Sprintf("%s%d_", internal.PlaceholderMark, len(p.originals))
+	p.originals = append(p.originals, original)
+	return s
+}
+
+// protect returns sql whose placeholders are replaced with sentinels.
+// Placeholders are found by the tokens, so colons of strings and array slices such as arr[1:2] are kept.
+func (p *placeholders) protect(sql string, conf *fmtconf.Config) string {
+	// text/template actions can not be tokenized
+	if conf.HasPlaceholderDialect(fmtconf.PLACEHOLDER_DIALECT_TEMPLATE) {
+		sql = templateActionRegexp.ReplaceAllStringFunc(sql, p.sentinel)
+	}
+	sql = p.protectFmtVerbs(sql)
+	sql = p.protectTokens(sql, conf)
+
+	// text/template actions of the other dialects are replaced only when the sql can not be parsed, ex) ORDER BY {{ .Sort }}
+	if _, err := pg_query.Parse(sql); err != nil && templateActionRegexp.MatchString(sql) {
+		sql = templateActionRegexp.ReplaceAllStringFunc(sql, p.sentinel)
+		// the tokens could not be scanned with the template actions
+		sql = p.protectTokens(sql, conf)
+	}
+
+	return sql
+}
+
+// protectFmtVerbs replaces the verbs of fmt.Sprintf, ex) %s, %d, %[1]v
+// The verb followed by a letter is the modulo operator or a LIKE pattern, ex) id%size, '%done'
+func (p *placeholders) protectFmtVerbs(sql string) string {
+	var bu strings.Builder
+	last := 0
+	for _, loc := range fmtVerbRegexp.FindAllStringIndex(sql, -1) {
+		verb := sql[loc[0]:loc[1]]
+		if verb == "%%" || (loc[1] < len(sql) && isIdentChar(sql[loc[1]])) {
+			continue
+		}
+		bu.WriteString(sql[last:loc[0]])
+		bu.WriteString(p.sentinel(verb))
+		last = loc[1]
+	}
+	bu.WriteString(sql[last:])
+	return bu.String()
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// protectTokens replaces the placeholders of the dialects that are found by the tokens
+func (p *placeholders) protectTokens(sql string, conf *fmtconf.Config) string {
+	res, err := pg_query.Scan(sql)
+	if err != nil {
+		// the parser reports the error
+		return sql
+	}
+	tokens := res.Tokens
+
+	var bu strings.Builder
+	last := 0
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		text := sql[t.Start:t.End]
+		// the name written right after the token, ex) :user_uuid, @user_uuid
+		nameFollows := i+1 < len(tokens) && tokens[i+1].Start == t.End &&
+			(tokens[i+1].Token == pg_query.Token_IDENT || tokens[i+1].KeywordKind != pg_query.KeywordKind_NO_KEYWORD)
+
+		start, end := int32(-1), int32(-1)
+		switch {
+		case t.Token == pg_query.Token_ASCII_91:
+			depth++
+		case t.Token == pg_query.Token_ASCII_93:
+			depth--
+		// the colon in brackets is an array slice, ex) arr[1:n]
+		case t.Token == pg_query.Token_ASCII_58 && depth == 0 && nameFollows &&
+			conf.HasPlaceholderDialect(fmtconf.PLACEHOLDER_DIALECT_COLON):
+			start, end = t.Start, tokens[i+1].End
+			i++
+		// an operator can be written without a space before the placeholder, ex) =@user_uuid, =?
+		case t.Token == pg_query.Token_Op && strings.HasSuffix(text, "@") && nameFollows &&
+			conf.HasPlaceholderDialect(fmtconf.PLACEHOLDER_DIALECT_AT):
+			start, end = t.End-1, tokens[i+1].End
+			i++
+		case t.Token == pg_query.Token_Op && strings.HasSuffix(text, "?") &&
+			conf.HasPlaceholderDialect(fmtconf.PLACEHOLDER_DIALECT_QUESTION):
+			start, end = t.End-1, t.End
+		case t.Token == pg_query.Token_IDENT && text == "sqlc" &&
+			conf.HasPlaceholderDialect(fmtconf.PLACEHOLDER_DIALECT_SQLC):
+			if k := sqlcMacroEnd(sql, tokens, i); k > 0 {
+				start, end = t.Start, tokens[k].End
+				i = k
+			}
+		}
+		if start < 0 {
+			continue
+		}
+
+		bu.WriteString(sql[last:start])
+		bu.WriteString(p.sentinel(sql[start:end]))
+		last = int(end)
+	}
+	bu.WriteString(sql[last:])
+
+	return bu.String()
+}
+
+// sqlcMacroEnd returns the index of the closing parenthesis of the sqlc macro that starts at i, or -1
+// ex) sqlc.arg(user_uuid), sqlc.narg('name')
+func sqlcMacroEnd(sql string, tokens []*pg_query.ScanToken, i int) int {
+	if i+3 >= len(tokens) || tokens[i+1].Token != pg_query.Token_ASCII_46 || tokens[i+3].Token != pg_query.Token_ASCII_40 {
+		return -1
+	}
+	switch sql[tokens[i+2].Start:tokens[i+2].End] {
+	case "arg", "narg", "slice":
+	default:
+		return -1
+	}
+
+	depth := 0
+	for k := i + 3; k < len(tokens); k++ {
+		switch tokens[k].Token {
+		case pg_query.Token_ASCII_40:
+			depth++
+		case pg_query.Token_ASCII_41:
+			depth--
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// restore returns formatted whose sentinels are replaced with the placeholders as written
+func (p *placeholders) restore(formatted string) string {
+	return sentinelRegexp.ReplaceAllStringFunc(formatted, func(s string) string {
+		n, err := strconv.Atoi(sentinelRegexp.FindStringSubmatch(s)[1])
+		if err != nil || n >= len(p.originals) {
+			return s
+		}
+		return p.originals[n]
+	})
+}
+
+// parseError returns the error of the parser for sql whose placeholders are replaced.
+// When the parser fails at a sentinel or right after it, PlaceholderError tells the placeholder.
+func (p *placeholders) parseError(sql string, err error) error {
+	var perr *parser.Error
+	if !errors.As(err, &perr) || perr.Cursorpos <= 0 {
+		return err
+	}
+	message := p.restore(perr.Message)
+
+	// the cursor position counts characters from 1
+	pos := len(sql)
+	if n := perr.Cursorpos - 1; n < utf8.RuneCountInString(sql) {
+		pos = len(string([]rune(sql)[:n]))
+	}
+	for _, loc := range sentinelRegexp.FindAllStringSubmatchIndex(sql, -1) {
+		// the error is at the sentinel or at the token after it
+		if loc[0] <= pos && (pos < loc[1] || strings.TrimSpace(sql[loc[1]:pos]) == "") {
+			n, _ := strconv.Atoi(sql[loc[2]:loc[3]])
+			if n >= len(p.originals) {
+				continue
+			}
+			return &PlaceholderError{
+				Placeholder: p.originals[n],
+				Line:        strings.Count(sql[:loc[0]], "\n") + 1,
+				Message:     message,
+			}
+		}
+	}
+
+	if message != perr.Message {
+		return errors.New(message)
+	}
+	return err
+}
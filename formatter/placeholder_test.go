@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPlaceholderError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		sql             string
+		wantPlaceholder string
+		wantLine        int
+		wantMessage     string
+	}{
+		{
+			name:            "sort direction",
+			sql:             "select name from users\norder by name %s",
+			wantPlaceholder: "%s",
+			wantLine:        2,
+			wantMessage:     `syntax error at or near "%s"`,
+		},
+		{
+			name:            "template action after expression",
+			sql:             "select name from users where name = $1 {{ .Cond }}",
+			wantPlaceholder: "{{ .Cond }}",
+			wantLine:        1,
+			wantMessage:     `syntax error at or near "{{ .Cond }}"`,
+		},
+		{
+			name: "syntax error without placeholder",
+			sql:  "select name from users where",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Format(tt.sql, fmtconf.NewDefaultConfig())
+			assert.Error(t, err)
+
+			var perr *PlaceholderError
+			if tt.wantPlaceholder == "" {
+				assert.False(t, errors.As(err, &perr))
+				return
+			}
+			if assert.True(t, errors.As(err, &perr)) {
+				assert.Equal(t, tt.wantPlaceholder, perr.Placeholder)
+				assert.Equal(t, tt.wantLine, perr.Line)
+				assert.Equal(t, tt.wantMessage, perr.Message)
+			}
+		})
+	}
+}
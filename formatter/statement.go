@@ -0,0 +1,79 @@
+package formatter
+
+import (
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// statementSource is the part of the input sql that belongs to a statement
+type statementSource struct {
+	// text is from the end of the previous statement to the end of the statement, including the comments
+	text string
+	// semicolon is true when the statement is terminated with a semicolon
+	semicolon bool
+}
+
+// splitStatementSources splits sql by the statement boundaries of the parse result.
+// a comment at the end of the line of a semicolon belongs to the statement before it,
+// and comments after the last statement belong to the last statement
+func splitStatementSources(sql string, stmts []*pg_query.RawStmt) ([]statementSource, error) {
+	res, err := pg_query.Scan(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]statementSource, len(stmts))
+	start := 0
+	for i, raw := range stmts {
+		end := len(sql)
+		if raw.StmtLen > 0 {
+			end = int(raw.StmtLocation + raw.StmtLen)
+		}
+
+		// find the semicolon after the statement
+		for ti, t := range res.Tokens {
+			if int(t.Start) < end {
+				continue
+			}
+			if t.Token != pg_query.Token_ASCII_59 {
+				break
+			}
+			sources[i].semicolon = true
+			end = int(t.End)
+			if ti+1 < len(res.Tokens) {
+				c := res.Tokens[ti+1]
+				isComment := c.Token == pg_query.Token_SQL_COMMENT || c.Token == pg_query.Token_C_COMMENT
+				if isComment && !strings.Contains(sql[t.End:c.Start], "\n") {
+					end = int(c.End)
+				}
+			}
+			break
+		}
+
+		if i == len(stmts)-1 {
+			end = len(sql)
+		}
+		sources[i].text = sql[start:end]
+		start = end
+	}
+	return sources, nil
+}
+
+// addSemicolon adds a semicolon after the last token, the comments after it are kept
+func addSemicolon(sql string) (string, error) {
+	res, err := pg_query.Scan(sql)
+	if err != nil {
+		return "", err
+	}
+	for i := len(res.Tokens) - 1; i >= 0; i-- {
+		t := res.Tokens[i]
+		if t.Token == pg_query.Token_ASCII_59 {
+			return sql, nil
+		}
+		if t.Token != pg_query.Token_SQL_COMMENT && t.Token != pg_query.Token_C_COMMENT {
+			return sql[:t.End] + ";" + sql[t.End:], nil
+		}
+	}
+	return sql, nil
+}
@@ -15,16 +15,20 @@ import (
 func formatBoolExpr(ctx context.Context, be *pg_query.Node_BoolExpr, indent int, conf *fmtconf.Config) (string, error) {
 	var bu strings.Builder
 
+	// ex) NOT EXISTS(...), user_uuid NOT IN(...)
+	if be.BoolExpr.Boolop == pg_query.BoolExprType_NOT_EXPR {
+		return nodeformatter.FormatExpr(ctx, &pg_query.Node{Node: be}, indent, conf)
+	}
+
 	for argI, arg := range be.BoolExpr.Args {
-		switch n := arg.Node.(type) {
-		case *pg_query.Node_AExpr:
-			res, err := nodeformatter.FormatAExpr(ctx, n, conf)
-			if err != nil {
-				return "", err
-			}
+		if n, ok := arg.Node.(*pg_query.Node_BoolExpr); ok && n.BoolExpr.Boolop != pg_query.BoolExprType_NOT_EXPR {
+			// the parenthesized condition is indented from the line of the opening parenthesis,
+			// ex) WHERE (\n  a\n    OR b\n)\n  AND c, WHERE a\n  AND (\n    b\n      OR c\n  )
+			lineIndent := indent
 			if argI != 0 {
+				lineIndent = indent + 1
 				bu.WriteString("\n")
-				for i := 0; i <= indent; i++ {
+				for i := 0; i < lineIndent; i++ {
 					bu.WriteString(internal.GetIndent(conf))
 				}
 				boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
@@ -34,75 +38,77 @@ func formatBoolExpr(ctx context.Context, be *pg_query.Node_BoolExpr, indent int,
 				bu.WriteString(boolStr)
 				bu.WriteString(" ")
 			}
-			bu.WriteString(res)
-		case *pg_query.Node_BoolExpr:
-			res, err := formatBoolExpr(ctx, n, indent+2, conf)
+			res, err := formatBoolExpr(ctx, n, lineIndent+1, conf)
 			if err != nil {
 				return "", err
 			}
-			if argI != 0 {
-				bu.WriteString("\n")
-				bu.WriteString(internal.GetIndent(conf))
-				boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(boolStr)
-			}
-			bu.WriteString(" ")
 			bu.WriteString("(")
 			bu.WriteString("\n")
-			bu.WriteString(internal.GetIndent(conf))
-			bu.WriteString(internal.GetIndent(conf))
+			for i := 0; i <= lineIndent; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
 			bu.WriteString(res)
 			bu.WriteString("\n")
-			bu.WriteString(internal.GetIndent(conf))
+			for i := 0; i < lineIndent; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
 			bu.WriteString(")")
-		case *pg_query.Node_NullTest:
-			res, err := nodeformatter.FormatNullTest(ctx, n)
+			continue
+		}
+
+		// the subquery is indented from the line of the condition, NOT binds tighter than AND and OR
+		argIndent := indent
+		if argI != 0 {
+			argIndent = indent + 1
+		}
+		res, err := nodeformatter.FormatExpr(ctx, arg, argIndent, conf)
+		if err != nil {
+			return "", err
+		}
+		if argI != 0 {
+			bu.WriteString("\n")
+			for i := 0; i <= indent; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
+			boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
 			if err != nil {
 				return "", err
 			}
-			if argI != 0 {
-				bu.WriteString("\n")
-				for i := 0; i <= indent; i++ {
-					bu.WriteString(internal.GetIndent(conf))
-				}
-				boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(boolStr)
-				bu.WriteString(" ")
-			}
-			bu.WriteString(res)
-		case *pg_query.Node_SubLink:
-			if selectStmt, ok := n.SubLink.Subselect.Node.(*pg_query.Node_SelectStmt); ok {
-				res, err := FormatSelectStmt(ctx, selectStmt, indent+1, conf)
-				if err != nil {
-					return "", err
-				}
+			bu.WriteString(boolStr)
+			bu.WriteString(" ")
+		}
+		bu.WriteString(res)
+	}
 
-				boolStr, err := enumconv.BoolExprTypeToString(be.BoolExpr.Boolop)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(boolStr)
-				bu.WriteString(" ")
+	return bu.String(), nil
+}
 
-				sbt, err := enumconv.SubLinkTypeToString(n.SubLink.SubLinkType)
-				if err != nil {
-					return "", err
-				}
-				bu.WriteString(sbt)
+// formatWhereClause outputs the WHERE clause of SELECT, UPDATE and DELETE
+func formatWhereClause(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
 
-				bu.WriteString("(\n")
-				bu.WriteString(res)
-				bu.WriteString("\n")
-				bu.WriteString(")")
-			}
-		}
+	res, err := formatWhereExpr(ctx, node, indent, conf)
+	if err != nil {
+		return "", err
 	}
+	bu.WriteString("\n")
+	for i := 0; i < indent; i++ {
+		bu.WriteString(internal.GetIndent(conf))
+	}
+	bu.WriteString("WHERE")
+	bu.WriteString(" ")
+	bu.WriteString(res)
 
 	return bu.String(), nil
 }
+
+// formatWhereExpr outputs a condition, indent is the indent of the line on which the condition starts
+func formatWhereExpr(ctx context.Context, node *pg_query.Node, indent int, conf *fmtconf.Config) (string, error) {
+	switch n := node.Node.(type) {
+	case *pg_query.Node_BoolExpr:
+		return formatBoolExpr(ctx, n, indent, conf)
+	case *pg_query.Node_CurrentOfExpr:
+		return "CURRENT OF " + nodeformatter.FormatIdentifier(n.CurrentOfExpr.CursorName, conf), nil
+	}
+	return nodeformatter.FormatExpr(ctx, node, indent, conf)
+}
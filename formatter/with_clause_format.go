@@ -0,0 +1,182 @@
+package formatter
+
+import (
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter/internal"
+	nodeformatter "github.com/Toru-Takagi/gopsqlfmt/formatter/node_formatter"
+
+	"context"
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// ex) WITH RECURSIVE t(n) AS (...)
+func formatWithClause(ctx context.Context, wc *pg_query.WithClause, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	for i := 0; i < indent; i++ {
+		bu.WriteString(internal.GetIndent(conf))
+	}
+	bu.WriteString("WITH")
+	if wc.Recursive {
+		bu.WriteString(" RECURSIVE")
+	}
+
+	for cteI, node := range wc.Ctes {
+		cte, ok := node.Node.(*pg_query.Node_CommonTableExpr)
+		if !ok {
+			continue
+		}
+		if cteI != 0 {
+			bu.WriteString(",")
+			bu.WriteString("\n")
+			for i := 0; i < indent; i++ {
+				bu.WriteString(internal.GetIndent(conf))
+			}
+		} else {
+			bu.WriteString(" ")
+		}
+
+		res, err := formatCommonTableExpr(ctx, cte, indent, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	}
+
+	return bu.String(), nil
+}
+
+func formatCommonTableExpr(ctx context.Context, cte *pg_query.Node_CommonTableExpr, indent int, conf *fmtconf.Config) (string, error) {
+	var bu strings.Builder
+
+	bu.WriteString(nodeformatter.FormatIdentifier(cte.CommonTableExpr.Ctename, conf))
+
+	// output column name
+	if len(cte.CommonTableExpr.Aliascolnames) > 0 {
+		bu.WriteString("(")
+		bu.WriteString(nodeformatter.FormatIdentifierList(cte.CommonTableExpr.Aliascolnames, conf))
+		bu.WriteString(")")
+	}
+
+	bu.WriteString(" AS")
+	switch cte.CommonTableExpr.Ctematerialized {
+	case pg_query.CTEMaterialize_CTEMaterializeAlways:
+		bu.WriteString(" MATERIALIZED")
+	case pg_query.CTEMaterialize_CTEMaterializeNever:
+		bu.WriteString(" NOT MATERIALIZED")
+	}
+	bu.WriteString(" (")
+	bu.WriteString("\n")
+
+	// output cte query
+	switch n := cte.CommonTableExpr.Ctequery.Node.(type) {
+	case *pg_query.Node_SelectStmt:
+		res, err := FormatSelectStmt(ctx, n, indent+1, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	case *pg_query.Node_InsertStmt:
+		res, err := formatInsertStmt(ctx, n, conf)
+		if err != nil {
+			return "", err
+		}
+		res, err = indentLines(res, indent+1, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	case *pg_query.Node_UpdateStmt:
+		res, err := formatUpdateStmt(ctx, n, conf)
+		if err != nil {
+			return "", err
+		}
+		res, err = indentLines(res, indent+1, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	case *pg_query.Node_DeleteStmt:
+		res, err := formatDeleteStmt(ctx, n, conf)
+		if err != nil {
+			return "", err
+		}
+		res, err = indentLines(res, indent+1, conf)
+		if err != nil {
+			return "", err
+		}
+		bu.WriteString(res)
+	default:
+		return "", fmt.Errorf("formatCommonTableExpr: unsupported cte query %T", n)
+	}
+
+	bu.WriteString("\n")
+	for i := 0; i < indent; i++ {
+		bu.WriteString(internal.GetIndent(conf))
+	}
+	bu.WriteString(")")
+
+	// output search clause
+	if sc := cte.CommonTableExpr.SearchClause; sc != nil {
+		bu.WriteString(" SEARCH")
+		if sc.SearchBreadthFirst {
+			bu.WriteString(" BREADTH FIRST BY ")
+		} else {
+			bu.WriteString(" DEPTH FIRST BY ")
+		}
+		bu.WriteString(nodeformatter.FormatIdentifierList(sc.SearchColList, conf))
+		bu.WriteString(" SET ")
+		bu.WriteString(nodeformatter.FormatIdentifier(sc.SearchSeqColumn, conf))
+	}
+
+	// output cycle clause
+	if cc := cte.CommonTableExpr.CycleClause; cc != nil {
+		bu.WriteString(" CYCLE ")
+		bu.WriteString(nodeformatter.FormatIdentifierList(cc.CycleColList, conf))
+		bu.WriteString(" SET ")
+		bu.WriteString(nodeformatter.FormatIdentifier(cc.CycleMarkColumn, conf))
+		// TO ... DEFAULT ... is output only when it is written, the parser fills in TRUE and FALSE without location
+		if c, ok := cc.CycleMarkValue.GetNode().(*pg_query.Node_AConst); cc.CycleMarkValue != nil && (!ok || c.AConst.Location >= 0) {
+			value, err := nodeformatter.FormatExpr(ctx, cc.CycleMarkValue, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			def, err := nodeformatter.FormatExpr(ctx, cc.CycleMarkDefault, indent, conf)
+			if err != nil {
+				return "", err
+			}
+			bu.WriteString(" TO ")
+			bu.WriteString(value)
+			bu.WriteString(" DEFAULT ")
+			bu.WriteString(def)
+		}
+		bu.WriteString(" USING ")
+		bu.WriteString(nodeformatter.FormatIdentifier(cc.CyclePathColumn, conf))
+	}
+
+	return bu.String(), nil
+}
+
+// indentLines indents every non-empty line of s, used for statements that are formatted without indent support.
+// the lines inside a multi-line token are not indented, ex) 'line1\nline2'
+func indentLines(s string, indent int, conf *fmtconf.Config) (string, error) {
+	tokens, _, err := scanSQL(s)
+	if err != nil {
+		return "", err
+	}
+
+	var bu strings.Builder
+	prefix := strings.Repeat(internal.GetIndent(conf), indent)
+	start := 0
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if _, inToken := tokenAt(tokens, start); line != "" && line != "\n" && !inToken {
+			bu.WriteString(prefix)
+		}
+		bu.WriteString(line)
+		start += len(line)
+	}
+	return bu.String(), nil
+}
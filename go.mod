@@ -7,15 +7,15 @@ toolchain go1.23.2
 require (
 	github.com/google/go-cmp v0.6.0
 	github.com/pganalyze/pg_query_go/v6 v6.1.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/tools v0.34.0
+	google.golang.org/protobuf v1.36.6
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/mod v0.25.0 // indirect
 	golang.org/x/sync v0.15.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
@@ -1,8 +1,26 @@
 package main
 
 import (
+	"os"
+
 	"github.com/Toru-Takagi/gopsqlfmt/analyzer"
+	"github.com/Toru-Takagi/gopsqlfmt/sqlcmd"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
-func main() { singlechecker.Main(analyzer.FormatSQLAnalyzer) }
+func main() {
+	// gopsqlfmt sql [-w] [-l] [-d] [path ...] formats .sql files
+	if len(os.Args) > 1 && os.Args[1] == "sql" {
+		os.Exit(sqlcmd.Run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	// -diff shows the fixes, so it implies -fix
+	for _, arg := range os.Args[1:] {
+		if arg == "-diff" || arg == "--diff" {
+			os.Args = append([]string{os.Args[0], "-fix"}, os.Args[1:]...)
+			break
+		}
+	}
+
+	singlechecker.Main(analyzer.FormatSQLAnalyzer)
+}
@@ -0,0 +1,175 @@
+package sqlcmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/formatter"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type options struct {
+	write bool
+	list  bool
+	diff  bool
+	conf  *fmtconf.Config
+}
+
+// Run runs `gopsqlfmt sql [-w] [-l] [-d] [path ...]`, the exit code is 2 if an error occurs like gofmt
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("sql", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gopsqlfmt sql [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+
+	var opts options
+	flags.BoolVar(&opts.write, "w", false, "write result to (source) file instead of stdout")
+	flags.BoolVar(&opts.list, "l", false, "list files whose formatting differs from gopsqlfmt's")
+	flags.BoolVar(&opts.diff, "d", false, "display diffs instead of rewriting files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	conf, err := fmtconf.LoadYamlConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	opts.conf = conf
+
+	if flags.NArg() == 0 {
+		if opts.write {
+			fmt.Fprintln(stderr, "error: cannot use -w with standard input")
+			return 2
+		}
+		if err := processFile("<standard input>", stdin, stdout, opts); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+			continue
+		}
+
+		if !info.IsDir() {
+			if err := processPath(path, stdout, opts); err != nil {
+				fmt.Fprintln(stderr, err)
+				exitCode = 2
+			}
+			continue
+		}
+
+		// format the .sql files in the directory
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".sql") {
+				return nil
+			}
+			if err := processPath(p, stdout, opts); err != nil {
+				fmt.Fprintln(stderr, err)
+				exitCode = 2
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+		}
+	}
+	return exitCode
+}
+
+func processPath(path string, stdout io.Writer, opts options) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return processFile(path, f, stdout, opts)
+}
+
+func processFile(filename string, in io.Reader, stdout io.Writer, opts options) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	res, err := FormatSQL(string(src), opts.conf)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	if res == string(src) {
+		if !opts.list && !opts.write && !opts.diff {
+			_, err := io.WriteString(stdout, res)
+			return err
+		}
+		return nil
+	}
+
+	if opts.list {
+		fmt.Fprintln(stdout, filename)
+	}
+	if opts.write {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, []byte(res), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if opts.diff {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(src)),
+			B:        difflib.SplitLines(res),
+			FromFile: filename + ".orig",
+			ToFile:   filename,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "diff -u %s.orig %s\n", filename, filename)
+		if _, err := io.WriteString(stdout, diff); err != nil {
+			return err
+		}
+	}
+	if !opts.list && !opts.write && !opts.diff {
+		_, err := io.WriteString(stdout, res)
+		return err
+	}
+	return nil
+}
+
+// FormatSQL formats the statements of a .sql file, every statement is terminated with a semicolon
+func FormatSQL(src string, conf *fmtconf.Config) (string, error) {
+	if strings.TrimSpace(src) == "" {
+		return "", nil
+	}
+
+	c := *conf
+	c.Statement.Semicolon = fmtconf.STATEMENT_SEMICOLON_ALWAYS
+	res, err := formatter.Format(src, &c)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(res, "\n"), nil
+}
@@ -0,0 +1,152 @@
+package sqlcmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Toru-Takagi/gopsqlfmt/fmtconf"
+	"github.com/Toru-Takagi/gopsqlfmt/sqlcmd"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSQL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "single statement without semicolon",
+			src:  "select id from users",
+			want: `SELECT
+  id
+FROM users;
+`,
+		},
+		{
+			name: "multiple statements",
+			src:  "select id from users; delete from users where id = 1;",
+			want: `SELECT
+  id
+FROM users;
+
+DELETE FROM users
+WHERE id = 1;
+`,
+		},
+		{
+			name: "comments",
+			src: `-- name: ListUsers :many
+select id from users; -- after list
+
+-- name: DeleteUser :exec
+delete from users where id = :id;
+-- end
+`,
+			want: `-- name: ListUsers :many
+SELECT
+  id
+FROM users; -- after list
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = :id;
+-- end
+`,
+		},
+		{
+			name: "comment only",
+			src:  "-- comment\n",
+			want: "-- comment\n",
+		},
+		{
+			name: "semicolon in string",
+			src:  "select ';' from users",
+			want: `SELECT
+  ';'
+FROM users;
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := sqlcmd.FormatSQL(tt.src, fmtconf.NewDefaultConfig())
+			assert.NoError(t, err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("FormatSQL() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	unformatted := filepath.Join(dir, "unformatted.sql")
+	formatted := filepath.Join(dir, "formatted.sql")
+	invalid := filepath.Join(dir, "invalid.sql")
+	assert.NoError(t, os.WriteFile(unformatted, []byte("select id from users"), 0o644))
+	assert.NoError(t, os.WriteFile(formatted, []byte("SELECT\n  id\nFROM users;\n"), 0o644))
+	assert.NoError(t, os.WriteFile(invalid, []byte("select from where"), 0o644))
+
+	t.Run("stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := sqlcmd.Run(nil, strings.NewReader("select id from users"), &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "SELECT\n  id\nFROM users;\n", stdout.String())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := sqlcmd.Run([]string{"-l", unformatted, formatted}, nil, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, unformatted+"\n", stdout.String())
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := sqlcmd.Run([]string{"-d", unformatted}, nil, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), "-select id from users")
+		assert.Contains(t, stdout.String(), "+SELECT")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := sqlcmd.Run([]string{"-l", invalid}, nil, &stdout, &stderr)
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr.String(), invalid)
+	})
+
+	t.Run("write with stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := sqlcmd.Run([]string{"-w"}, strings.NewReader("select 1"), &stdout, &stderr)
+		assert.Equal(t, 2, code)
+	})
+}
+
+func TestRunWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.sql")
+	assert.NoError(t, os.WriteFile(path, []byte("select id from users"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := sqlcmd.Run([]string{"-w", dir}, nil, &stdout, &stderr)
+	assert.Equal(t, 0, code)
+
+	got, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT\n  id\nFROM users;\n", string(got))
+}
@@ -1,6 +1,10 @@
 package sqlconst
 
+import "database/sql"
+
 func main() {
+	var db *sql.DB
+
 	const sql = `
 		SELECT
 			user_uuid       ,
@@ -19,19 +23,26 @@ func main() {
 		FROM users4
 	`
 
-	// q := `
-	// 	INSERT INTO users (
-	// 		user_uuid
-	// 	) VALUES (
-	// 		$1
-	// 	)
-	// `
-
-	// var query = `
-	// 	INSERT INTO users(
-	// 		user_uuid
-	// 	) VALUES (
-	// 		$1
-	// 	)
-	// `
+	q := `
+		INSERT INTO users (
+			user_uuid
+		) VALUES (
+			$1
+		)
+	`
+
+	var query = `
+		INSERT INTO users(
+			user_uuid
+		) VALUES (
+			$1
+		)
+	`
+
+	db.Query(sql)
+	db.Query(sql2)
+	db.Query(sql3)
+	db.Query(sql4)
+	db.Exec(q)
+	db.Exec(query)
 }